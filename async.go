@@ -0,0 +1,35 @@
+package dynconf
+
+import "context"
+
+// startWorkers starts the goroutines running the callbacks until ctx is canceled, see WithAsyncCallbacks.
+func (c *Config) startWorkers(ctx context.Context) {
+	c.jobs = make(chan func(), c.workers)
+	for i := 0; i < c.workers; i++ {
+		go func() {
+			for {
+				select {
+				case job := <-c.jobs:
+					job()
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+}
+
+// runCallbacks calls the update callbacks with the changes,
+// or hands them over to the workers if WithAsyncCallbacks is set.
+// It blocks when all the workers are busy and their queue is full.
+func (c *Config) runCallbacks(changes []Change) {
+	if c.jobs == nil {
+		c.callback(changes)
+		return
+	}
+
+	select {
+	case c.jobs <- func() { c.callback(changes) }:
+	case <-c.done:
+	}
+}
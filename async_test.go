@@ -0,0 +1,54 @@
+package dynconf
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestAsyncCallbacks(t *testing.T) {
+	var (
+		release = make(chan struct{})
+		done    = make(chan []Change, 2)
+	)
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New(
+		"/configs/curiosity/",
+		WithLogger(logger),
+		WithAsyncCallbacks(1),
+		WithOnChanges(func(changes []Change) {
+			<-release
+			done <- changes
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// The updates are applied while the callback is blocked.
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/velocity", "5", 2)}, 2))
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/velocity", "10", 3)}, 3))
+	if got := c.Integer("velocity", 0); got != 10 {
+		t.Errorf("expected velocity 10 got %d", got)
+	}
+
+	close(release)
+	for _, want := range []string{"5", "10"} {
+		select {
+		case changes := <-done:
+			if got := changes[0].New; got != want {
+				t.Errorf("expected velocity %s got %s", want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected callback")
+		}
+	}
+}
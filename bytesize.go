@@ -0,0 +1,85 @@
+package dynconf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// byteUnits are the multipliers of the case-insensitive size units,
+// where the decimal units are powers of 1000 and the binary ones are powers of 1024.
+var byteUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"pb":  1e15,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+	"pib": 1 << 50,
+}
+
+// parseByteSize parses the size such as "512MiB", "2GB", "1.5 GiB", or "1024" into bytes.
+func parseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i == -1 {
+		i = len(s)
+	}
+	num, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	m, ok := byteUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit %q", unit)
+	}
+
+	if n, err := strconv.ParseInt(num, 10, 64); err == nil {
+		if n > math.MaxInt64/m {
+			return 0, errors.New("size overflows int64")
+		}
+		return n * m, nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, err
+	}
+	f *= float64(m)
+	if f >= math.MaxInt64 {
+		return 0, errors.New("size overflows int64")
+	}
+
+	return int64(f), nil
+}
+
+// ByteSize returns the size in bytes of the given setting, e.g., 536870912 for "512MiB",
+// or defaultValue if it wasn't found or parsing failed.
+// The units are B, KB, MB, GB, TB, PB (powers of 1000) and KiB, MiB, GiB, TiB, PiB (powers of 1024),
+// and a value without a unit is in bytes.
+func (c *Config) ByteSize(setting string, defaultValue int64) int64 {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	n, err := parseByteSize(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid byte size setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return n
+}
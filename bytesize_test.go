@@ -0,0 +1,78 @@
+package dynconf
+
+import (
+	"os"
+	"testing"
+
+	"github.com/go-kit/log"
+)
+
+func TestConfigByteSize(t *testing.T) {
+	const defaultCacheSize int64 = 1 << 20
+
+	tests := map[string]struct {
+		in   interface{}
+		want int64
+	}{
+		"bytes": {
+			in:   "1024",
+			want: 1024,
+		},
+		"binary unit": {
+			in:   "512MiB",
+			want: 512 << 20,
+		},
+		"decimal unit": {
+			in:   "2GB",
+			want: 2e9,
+		},
+		"lowercase with space": {
+			in:   "4 kib",
+			want: 4096,
+		},
+		"fraction": {
+			in:   "1.5GiB",
+			want: 3 << 29,
+		},
+		"overflow": {
+			in:   "9000PiB",
+			want: defaultCacheSize,
+		},
+		"negative": {
+			in:   "-1MiB",
+			want: defaultCacheSize,
+		},
+		"unknown unit": {
+			in:   "10MiBs",
+			want: defaultCacheSize,
+		},
+		"no number": {
+			in:   "MiB",
+			want: defaultCacheSize,
+		},
+		"int": {
+			in:   1024,
+			want: defaultCacheSize,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("cache_size", tc.in)
+			if got := c.ByteSize("cache_size", defaultCacheSize); tc.want != got {
+				t.Errorf("expected %d got %d", tc.want, got)
+			}
+		})
+	}
+}
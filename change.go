@@ -0,0 +1,251 @@
+package dynconf
+
+import (
+	"context"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Change describes a change of a setting.
+type Change struct {
+	// Setting is the name of the changed setting.
+	Setting string
+	// Old is the previous value of the setting, empty if the setting didn't exist.
+	Old string
+	// New is the new value of the setting, empty if the setting was deleted.
+	New string
+	// Deleted indicates that the setting was deleted.
+	Deleted bool
+}
+
+// AddListener registers a function to be called with the settings' changes of each update,
+// so several subsystems can react to the changes independently, unlike WithOnUpdate.
+// The listeners are called in no particular order right after the settings are updated,
+// and they shouldn't block since they hold back the following updates.
+// The returned function removes the listener.
+func (c *Config) AddListener(fn func(changes []Change)) (remove func()) {
+	c.mu.Lock()
+	id := c.nextListenerID
+	c.nextListenerID++
+	c.listeners[id] = fn
+	c.mu.Unlock()
+
+	return func() {
+		c.mu.Lock()
+		delete(c.listeners, id)
+		c.mu.Unlock()
+	}
+}
+
+// dispatch calls the listeners with the settings' changes.
+func (c *Config) dispatch(changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	ll := make([]func([]Change), 0, len(c.listeners))
+	for _, fn := range c.listeners {
+		ll = append(ll, fn)
+	}
+	c.mu.Unlock()
+
+	for _, fn := range ll {
+		c.protect("listener", func() { fn(changes) })
+	}
+}
+
+// protect calls f and logs the panic if f panics,
+// so a faulty callback can't stop watching the settings.
+func (c *Config) protect(callback string, f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.logger.Log("msg", "dynconf callback panicked", "path", c.path, "callback", callback, "err", r, "stack", string(debug.Stack()))
+		}
+	}()
+
+	f()
+}
+
+// OnChange registers fn to be called whenever the given setting is set or deleted,
+// with its previous and new values, e.g., to reconnect a client when its address changes.
+// The old value is empty if the setting didn't exist, and the new one is empty if it was deleted.
+// The returned function removes the callback.
+func (c *Config) OnChange(setting string, fn func(old, new string, deleted bool)) (remove func()) {
+	return c.AddListener(func(changes []Change) {
+		for _, ch := range changes {
+			if ch.Setting == setting {
+				fn(ch.Old, ch.New, ch.Deleted)
+			}
+		}
+	})
+}
+
+// StreamTo sends every setting change to out until ctx is done.
+// The changes are sent in the order they happened.
+// Note, the send blocks until out is ready to receive,
+// so a slow reader holds back the settings updates.
+func (c *Config) StreamTo(ctx context.Context, out chan<- Change) {
+	remove := c.AddListener(func(changes []Change) {
+		for _, ch := range changes {
+			select {
+			case out <- ch:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	defer remove()
+
+	<-ctx.Done()
+}
+
+// UpdatedAt returns the time when the given setting was last loaded, updated or deleted.
+func (c *Config) UpdatedAt(setting string) (time.Time, bool) {
+	v, ok := c.changedAt.Load(setting)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	t, _ := v.(time.Time)
+	return t, true
+}
+
+// ChangedSince returns the sorted names of the settings that were loaded, updated or deleted after t,
+// e.g., so a periodic reconciler can process only the recently changed settings.
+func (c *Config) ChangedSince(t time.Time) []string {
+	var ss []string
+	c.changedAt.Range(func(key interface{}, value interface{}) bool {
+		if at, _ := value.(time.Time); at.After(t) {
+			k, _ := key.(string)
+			ss = append(ss, k)
+		}
+		return true
+	})
+	sort.Strings(ss)
+
+	return ss
+}
+
+// Diff returns the changes that turn the old settings into the new ones sorted by the setting name.
+func Diff(old, new map[string]string) []Change {
+	var changes []Change
+	for k, o := range old {
+		n, ok := new[k]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Setting: k, Old: o, Deleted: true})
+		case o != n:
+			changes = append(changes, Change{Setting: k, Old: o, New: n})
+		}
+	}
+	for k, n := range new {
+		if _, ok := old[k]; !ok {
+			changes = append(changes, Change{Setting: k, New: n})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Setting < changes[j].Setting
+	})
+
+	return changes
+}
+
+// DiffAgainst returns the changes that turn the settings of c into the settings of other,
+// e.g., to check that two Configs backed by different etcd clusters agree.
+func (c *Config) DiffAgainst(other *Config) []Change {
+	return Diff(c.Settings(), other.Settings())
+}
+
+// subscribe returns a channel that receives the value returned by get right away and then whenever the setting changes,
+// and a function which stops the subscription and closes the channel.
+// The listener is added before the first value is sent, and get is called under the same lock as the sends,
+// so the channel never ends up with a value older than the setting's current one.
+// Only the latest value is kept if the reader falls behind.
+func subscribe[T any](c *Config, setting string, get func() T) (<-chan T, func()) {
+	var (
+		mu     sync.Mutex
+		closed bool
+	)
+	out := make(chan T, 1)
+	send := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		v := get()
+		select {
+		case <-out:
+		default:
+		}
+		out <- v
+	}
+
+	remove := c.AddListener(func(changes []Change) {
+		for _, ch := range changes {
+			if ch.Setting == setting {
+				send()
+				return
+			}
+		}
+	})
+	send()
+
+	var once sync.Once
+	return out, func() {
+		once.Do(func() {
+			remove()
+			mu.Lock()
+			closed = true
+			close(out)
+			mu.Unlock()
+		})
+	}
+}
+
+// Subscribe returns a channel that receives the current raw value of the given setting
+// and then its new value on every change, where the empty string means the setting isn't found,
+// and a function which stops the subscription and closes the channel.
+// Only the latest value is kept if the reader falls behind.
+func (c *Config) Subscribe(setting string) (<-chan string, func()) {
+	return subscribe(c, setting, func() string {
+		v, _ := c.Lookup(setting)
+		return v
+	})
+}
+
+// SubscribeString returns a channel that receives the current string value of the given setting
+// and then its new value on every change, or defaultValue if it wasn't found,
+// and a function which stops the subscription and closes the channel.
+// Only the latest value is kept if the reader falls behind.
+func (c *Config) SubscribeString(setting string, defaultValue string) (<-chan string, func()) {
+	return subscribe(c, setting, func() string { return c.String(setting, defaultValue) })
+}
+
+// SubscribeBoolean returns a channel that receives the current boolean value of the given setting
+// and then its new value on every change, or defaultValue if it wasn't found or parsing failed,
+// and a function which stops the subscription and closes the channel.
+// Only the latest value is kept if the reader falls behind.
+func (c *Config) SubscribeBoolean(setting string, defaultValue bool) (<-chan bool, func()) {
+	return subscribe(c, setting, func() bool { return c.Boolean(setting, defaultValue) })
+}
+
+// SubscribeInteger returns a channel that receives the current integer value of the given setting
+// and then its new value on every change, or defaultValue if it wasn't found or parsing failed,
+// and a function which stops the subscription and closes the channel.
+// Only the latest value is kept if the reader falls behind.
+func (c *Config) SubscribeInteger(setting string, defaultValue int) (<-chan int, func()) {
+	return subscribe(c, setting, func() int { return c.Integer(setting, defaultValue) })
+}
+
+// SubscribeFloat returns a channel that receives the current float value of the given setting
+// and then its new value on every change, or defaultValue if it wasn't found or parsing failed,
+// and a function which stops the subscription and closes the channel.
+// Only the latest value is kept if the reader falls behind.
+func (c *Config) SubscribeFloat(setting string, defaultValue float64) (<-chan float64, func()) {
+	return subscribe(c, setting, func() float64 { return c.Float(setting, defaultValue) })
+}
@@ -0,0 +1,569 @@
+package dynconf
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/google/go-cmp/cmp"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// putEvent returns an etcd event of the setting being set to value.
+func putEvent(key, value string, rev int64) *clientv3.Event {
+	return &clientv3.Event{
+		Type: clientv3.EventTypePut,
+		Kv: &mvccpb.KeyValue{
+			Key:         []byte(key),
+			Value:       []byte(value),
+			ModRevision: rev,
+		},
+	}
+}
+
+// deleteEvent returns an etcd event of the setting being deleted.
+func deleteEvent(key string, rev int64) *clientv3.Event {
+	return &clientv3.Event{
+		Type: clientv3.EventTypeDelete,
+		Kv: &mvccpb.KeyValue{
+			Key:         []byte(key),
+			ModRevision: rev,
+		},
+	}
+}
+
+// waitListeners waits until n listeners are registered.
+func waitListeners(t *testing.T, c *Config, n int) {
+	t.Helper()
+
+	for i := 0; i < 100; i++ {
+		c.mu.Lock()
+		got := len(c.listeners)
+		c.mu.Unlock()
+		if got >= n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d listeners", n)
+}
+
+func TestStreamTo(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := make(chan Change)
+	go c.StreamTo(ctx, out)
+	waitListeners(t, c, 1)
+
+	go func() {
+		c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+			putEvent("/configs/curiosity/velocity", "5", 2),
+			putEvent("/configs/curiosity/velocity", "10", 2),
+		}, 2))
+		c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+			deleteEvent("/configs/curiosity/velocity", 3),
+		}, 3))
+	}()
+
+	want := []Change{
+		{Setting: "velocity", New: "5"},
+		{Setting: "velocity", Old: "5", New: "10"},
+		{Setting: "velocity", Old: "10", Deleted: true},
+	}
+	var got []Change
+	for range want {
+		select {
+		case ch := <-out:
+			got = append(got, ch)
+		case <-time.After(time.Second):
+			t.Fatalf("expected %d changes got %v", len(want), got)
+		}
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestOnChange(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	var got []Change
+	remove := c.OnChange("velocity", func(old, new string, deleted bool) {
+		got = append(got, Change{Setting: "velocity", Old: old, New: new, Deleted: deleted})
+	})
+
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+		putEvent("/configs/curiosity/velocity", "5", 2),
+		putEvent("/configs/curiosity/name", "curiosity", 2),
+		putEvent("/configs/curiosity/velocity", "10", 2),
+	}, 2))
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+		deleteEvent("/configs/curiosity/velocity", 3),
+	}, 3))
+	remove()
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+		putEvent("/configs/curiosity/velocity", "1", 4),
+	}, 4))
+
+	want := []Change{
+		{Setting: "velocity", New: "5"},
+		{Setting: "velocity", Old: "5", New: "10"},
+		{Setting: "velocity", Old: "10", Deleted: true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestOnDelete(t *testing.T) {
+	var deleted []string
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithOnDelete(func(setting string) {
+		deleted = append(deleted, setting)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+		putEvent("/configs/curiosity/velocity", "5", 2),
+		putEvent("/configs/curiosity/name", "curiosity", 2),
+	}, 2))
+	if deleted != nil {
+		t.Fatalf("expected no deletions got %v", deleted)
+	}
+
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+		deleteEvent("/configs/curiosity/velocity", 3),
+		putEvent("/configs/curiosity/name", "opportunity", 3),
+	}, 3))
+	if diff := cmp.Diff([]string{"velocity"}, deleted); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestOnChanges(t *testing.T) {
+	var got [][]Change
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithOnChanges(func(changes []Change) {
+		got = append(got, changes)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+		putEvent("/configs/curiosity/velocity", "5", 2),
+		putEvent("/configs/curiosity/name", "curiosity", 2),
+	}, 2))
+	// Nothing has changed.
+	c.update(newEtcdUpdate(c.path, nil, 3))
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+		deleteEvent("/configs/curiosity/velocity", 4),
+	}, 4))
+
+	want := [][]Change{
+		{
+			{Setting: "velocity", New: "5"},
+			{Setting: "name", New: "curiosity"},
+		},
+		{
+			{Setting: "velocity", Old: "5", Deleted: true},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestPanickingCallbacks(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New(
+		"/configs/curiosity/",
+		WithLogger(logger),
+		WithOnUpdate(func(map[string]string) { panic("onUpdate") }),
+		WithOnDelete(func(string) { panic("onDelete") }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.OnChange("velocity", func(old, new string, deleted bool) { panic("listener") })
+	var got []string
+	c.OnChange("velocity", func(old, new string, deleted bool) { got = append(got, new) })
+
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/velocity", "5", 2)}, 2))
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{deleteEvent("/configs/curiosity/velocity", 3)}, 3))
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/velocity", "10", 4)}, 4))
+
+	if got := c.Integer("velocity", 0); got != 10 {
+		t.Errorf("expected velocity 10 got %d", got)
+	}
+	if len(got) != 3 {
+		t.Errorf("expected 3 changes got %v", got)
+	}
+}
+
+func TestAddListener(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	var a, b [][]Change
+	removeA := c.AddListener(func(changes []Change) { a = append(a, changes) })
+	removeB := c.AddListener(func(changes []Change) { b = append(b, changes) })
+	defer removeB()
+
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/velocity", "5", 2)}, 2))
+	removeA()
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/velocity", "10", 3)}, 3))
+
+	want := [][]Change{{{Setting: "velocity", New: "5"}}}
+	if diff := cmp.Diff(want, a); diff != "" {
+		t.Error(diff)
+	}
+	want = append(want, []Change{{Setting: "velocity", Old: "5", New: "10"}})
+	if diff := cmp.Diff(want, b); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestDiffAgainst(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	a, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	if got := a.DiffAgainst(b); got != nil {
+		t.Errorf("expected no changes got %v", got)
+	}
+
+	a.settings.Store("velocity", "5")
+	a.settings.Store("is_camera_enabled", "true")
+	a.settings.Store("name", "curiosity")
+	b.settings.Store("velocity", "10")
+	b.settings.Store("is_camera_enabled", "true")
+	b.settings.Store("mission", "mars")
+
+	want := []Change{
+		{Setting: "mission", New: "mars"},
+		{Setting: "name", Old: "curiosity", Deleted: true},
+		{Setting: "velocity", Old: "5", New: "10"},
+	}
+	if diff := cmp.Diff(want, a.DiffAgainst(b)); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestSubscribeInteger(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.settings.Store("velocity", "1")
+	velocity, cancel := c.SubscribeInteger("velocity", 10)
+
+	receive := func() int {
+		select {
+		case v := <-velocity:
+			return v
+		case <-time.After(time.Second):
+			t.Fatal("expected velocity")
+		}
+		return 0
+	}
+
+	if got := receive(); got != 1 {
+		t.Errorf("expected velocity %d got %d", 1, got)
+	}
+
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/velocity", "5", 2)}, 2))
+	if got := receive(); got != 5 {
+		t.Errorf("expected velocity %d got %d", 5, got)
+	}
+
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/velocity", "abc", 3)}, 3))
+	if got := receive(); got != 10 {
+		t.Errorf("expected velocity %d got %d", 10, got)
+	}
+
+	// Other settings' changes aren't sent.
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/name", "curiosity", 4)}, 4))
+	select {
+	case v := <-velocity:
+		t.Errorf("unexpected velocity %d", v)
+	default:
+	}
+
+	cancel()
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/velocity", "7", 5)}, 5))
+	if v, ok := <-velocity; ok {
+		t.Errorf("expected closed channel got %d", v)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.settings.Store("name", "curiosity")
+	name, cancel := c.Subscribe("name")
+
+	receive := func() string {
+		select {
+		case v := <-name:
+			return v
+		case <-time.After(time.Second):
+			t.Fatal("expected name")
+		}
+		return ""
+	}
+
+	if got := receive(); got != "curiosity" {
+		t.Errorf("expected name %q got %q", "curiosity", got)
+	}
+
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/name", "opportunity", 2)}, 2))
+	if got := receive(); got != "opportunity" {
+		t.Errorf("expected name %q got %q", "opportunity", got)
+	}
+
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{deleteEvent("/configs/curiosity/name", 3)}, 3))
+	if got := receive(); got != "" {
+		t.Errorf("expected no name got %q", got)
+	}
+
+	cancel()
+	cancel()
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/name", "spirit", 4)}, 4))
+	if v, ok := <-name; ok {
+		t.Errorf("expected closed channel got %q", v)
+	}
+}
+
+func TestSubscribeMissedUpdate(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.settings.Store("name", "curiosity")
+	name, cancel := c.Subscribe("name")
+	t.Cleanup(cancel)
+
+	// The change made before the first receive isn't lost.
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/name", "opportunity", 2)}, 2))
+	select {
+	case v := <-name:
+		if v != "opportunity" {
+			t.Errorf("expected name %q got %q", "opportunity", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected name")
+	}
+	select {
+	case v := <-name:
+		t.Errorf("unexpected name %q", v)
+	default:
+	}
+}
+
+func TestChangedSince(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	start := time.Date(2021, 12, 1, 10, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return start }
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/velocity", "5", 2)}, 2))
+	c.now = func() time.Time { return start.Add(time.Hour) }
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+		putEvent("/configs/curiosity/name", "curiosity", 3),
+		putEvent("/configs/curiosity/is_camera_enabled", "true", 3),
+	}, 3))
+
+	got := c.ChangedSince(start.Add(time.Minute))
+	want := []string{"is_camera_enabled", "name"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+
+	got = c.ChangedSince(start.Add(-time.Minute))
+	want = []string{"is_camera_enabled", "name", "velocity"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+
+	if at, ok := c.UpdatedAt("velocity"); !ok || !at.Equal(start) {
+		t.Errorf("expected velocity updated at %s got %s", start, at)
+	}
+}
+
+func TestOnRawEvent(t *testing.T) {
+	var got []*clientv3.Event
+	onRawEvent := func(events []*clientv3.Event) {
+		got = append(got, events...)
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithOnRawEvent(onRawEvent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/velocity", "5", 2)}, 2))
+	overwrite := putEvent("/configs/curiosity/velocity", "7", 3)
+	overwrite.PrevKv = &mvccpb.KeyValue{Key: overwrite.Kv.Key, Value: []byte("5"), ModRevision: 2}
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{overwrite}, 3))
+	del := deleteEvent("/configs/curiosity/velocity", 4)
+	del.PrevKv = &mvccpb.KeyValue{Key: del.Kv.Key, Value: []byte("7"), ModRevision: 3}
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{del}, 4))
+
+	if len(got) != 3 {
+		t.Fatalf("expected 3 events got %d", len(got))
+	}
+	if got[0].Type != clientv3.EventTypePut || got[0].Kv.ModRevision != 2 || got[0].PrevKv != nil {
+		t.Errorf("expected put event at revision 2 got %v", got[0])
+	}
+	if got[1].Type != clientv3.EventTypePut || got[1].Kv.ModRevision != 3 || got[1].PrevKv == nil || string(got[1].PrevKv.Value) != "5" {
+		t.Errorf("expected put event over velocity 5 at revision 3 got %v", got[1])
+	}
+	if got[2].Type != clientv3.EventTypeDelete || got[2].Kv.ModRevision != 4 || got[2].PrevKv == nil || string(got[2].PrevKv.Value) != "7" {
+		t.Errorf("expected delete event of velocity 7 at revision 4 got %v", got[2])
+	}
+}
+
+func TestOnRawEventPrevKV(t *testing.T) {
+	etcd := newEtcdClient(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if r, err := etcd.Put(ctx, "/configs/curiosity/velocity", "5"); err != nil {
+		t.Fatalf("failed to put velocity=5 setting: %v %v", err, r)
+	}
+
+	events := make(chan *clientv3.Event, 2)
+	onRawEvent := func(ee []*clientv3.Event) {
+		for _, e := range ee {
+			events <- e
+		}
+	}
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithEtcdClient(etcd), WithLogger(logger), WithOnRawEvent(onRawEvent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if err = c.Ready(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Wait for the watch to be established.
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err = etcd.Put(ctx, "/configs/curiosity/velocity", "7"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = etcd.Delete(ctx, "/configs/curiosity/velocity"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"5", "7"} {
+		select {
+		case e := <-events:
+			if e.PrevKv == nil || string(e.PrevKv.Value) != want {
+				t.Errorf("expected previous velocity %s got %v", want, e.PrevKv)
+			}
+		case <-ctx.Done():
+			t.Fatal("expected event")
+		}
+	}
+}
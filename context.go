@@ -0,0 +1,71 @@
+package dynconf
+
+import (
+	"context"
+	"time"
+)
+
+// The Context getters wait for the settings to be loaded from etcd before reading them.
+// If the settings aren't loaded until ctx is done, the default value is returned.
+// When ctx has no deadline, the wait is bounded by WithReadTimeout if it's set.
+
+// readyContext waits until the Config is ready to use or ctx is done.
+func (c *Config) readyContext(ctx context.Context, setting string) bool {
+	if _, ok := ctx.Deadline(); !ok && c.readTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.readTimeout)
+		defer cancel()
+	}
+
+	if err := c.Ready(ctx); err != nil {
+		c.logger.Log("msg", "dynconf setting read before ready", "path", c.path, "setting", setting, "err", err)
+		return false
+	}
+
+	return true
+}
+
+// StringContext returns the string value of the given setting once the settings are loaded,
+// or defaultValue if it wasn't found or the settings weren't loaded until ctx is done.
+func (c *Config) StringContext(ctx context.Context, setting, defaultValue string) string {
+	if !c.readyContext(ctx, setting) {
+		return defaultValue
+	}
+	return c.String(setting, defaultValue)
+}
+
+// BooleanContext returns the boolean value of the given setting once the settings are loaded,
+// or defaultValue if it wasn't found, parsing failed, or the settings weren't loaded until ctx is done.
+func (c *Config) BooleanContext(ctx context.Context, setting string, defaultValue bool) bool {
+	if !c.readyContext(ctx, setting) {
+		return defaultValue
+	}
+	return c.Boolean(setting, defaultValue)
+}
+
+// IntegerContext returns the integer value of the given setting once the settings are loaded,
+// or defaultValue if it wasn't found, parsing failed, or the settings weren't loaded until ctx is done.
+func (c *Config) IntegerContext(ctx context.Context, setting string, defaultValue int) int {
+	if !c.readyContext(ctx, setting) {
+		return defaultValue
+	}
+	return c.Integer(setting, defaultValue)
+}
+
+// FloatContext returns the float value of the given setting once the settings are loaded,
+// or defaultValue if it wasn't found, parsing failed, or the settings weren't loaded until ctx is done.
+func (c *Config) FloatContext(ctx context.Context, setting string, defaultValue float64) float64 {
+	if !c.readyContext(ctx, setting) {
+		return defaultValue
+	}
+	return c.Float(setting, defaultValue)
+}
+
+// DurationContext returns the duration value of the given setting once the settings are loaded,
+// or defaultValue if it wasn't found, parsing failed, or the settings weren't loaded until ctx is done.
+func (c *Config) DurationContext(ctx context.Context, setting string, defaultValue time.Duration) time.Duration {
+	if !c.readyContext(ctx, setting) {
+		return defaultValue
+	}
+	return c.Duration(setting, defaultValue)
+}
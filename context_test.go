@@ -0,0 +1,46 @@
+package dynconf
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestReadTimeout(t *testing.T) {
+	// etcd is unreachable, so the settings are never loaded.
+	etcd, err := clientv3.New(clientv3.Config{
+		Endpoints: []string{"127.0.0.1:1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithEtcdClient(etcd), WithLogger(logger), WithReadTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	c.settings.Store("velocity", "5")
+
+	start := time.Now()
+	if got := c.IntegerContext(context.Background(), "velocity", 10); got != 10 {
+		t.Errorf("expected velocity %d got %d", 10, got)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected to wait for the read timeout got %s", elapsed)
+	}
+
+	c.markReady()
+	if got := c.IntegerContext(context.Background(), "velocity", 10); got != 5 {
+		t.Errorf("expected velocity %d got %d", 5, got)
+	}
+}
@@ -0,0 +1,141 @@
+// Package cueschema provides a dynconf.Validator which checks all the settings of a config against a CUE schema,
+// so the types, ranges, and the rules spanning several settings are declared in one place, e.g.,
+//
+//	velocity:     int & >=0 & <=max_velocity
+//	max_velocity: int | *100
+//	mode:         "drive" | "park"
+//
+// The Validator is meant to be used with dynconf.WithValidator.
+package cueschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	cueerrors "cuelang.org/go/cue/errors"
+	"github.com/pooyakn/dynconf"
+)
+
+// Validator checks the settings against a CUE schema.
+type Validator struct {
+	ctx    *cue.Context
+	schema cue.Value
+	// kinds are the kinds of the top-level fields, including the optional ones, by their labels.
+	kinds map[string]cue.Kind
+}
+
+var _ dynconf.Validator = (*Validator)(nil)
+
+// New compiles the CUE schema, e.g., the contents of a .cue file.
+func New(src []byte) (*Validator, error) {
+	ctx := cuecontext.New()
+	schema := ctx.CompileBytes(src)
+	if err := schema.Err(); err != nil {
+		return nil, fmt.Errorf("cueschema failed to compile schema: %w", err)
+	}
+
+	kinds := make(map[string]cue.Kind)
+	fields, err := schema.Fields(cue.Optional(true))
+	if err != nil {
+		return nil, fmt.Errorf("cueschema invalid schema: %w", err)
+	}
+	for fields.Next() {
+		kinds[fields.Label()] = fields.Value().IncompleteKind()
+	}
+
+	return &Validator{ctx: ctx, schema: schema, kinds: kinds}, nil
+}
+
+// Validate unifies the settings with the schema and reports the violations as *Error.
+// The settings' values are typed according to the schema: a value is decoded as an integer, a float,
+// a boolean, or a JSON object or array only if its field doesn't accept strings and expects that type,
+// so the values of the string fields, or of the settings the schema doesn't declare, are always strings.
+func (v *Validator) Validate(settings map[string]string) error {
+	doc := make(map[string]interface{}, len(settings))
+	for k, s := range settings {
+		if kind, ok := v.kinds[k]; ok {
+			doc[k] = decode(s, kind)
+		} else {
+			doc[k] = s
+		}
+	}
+
+	value := v.schema.Unify(v.ctx.Encode(doc))
+	if err := value.Validate(cue.Concrete(true)); err != nil {
+		return newError(err)
+	}
+
+	return nil
+}
+
+// Error lists the violations of the schema.
+type Error struct {
+	Violations []Violation
+}
+
+// Violation describes a value which doesn't satisfy the schema.
+type Violation struct {
+	// Path is the path to the invalid value, e.g., velocity, or camera.fps for a JSON setting.
+	Path string
+	// Message explains the violation.
+	Message string
+}
+
+func (e *Error) Error() string {
+	ss := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		ss = append(ss, v.Path+": "+v.Message)
+	}
+
+	return "cueschema invalid settings: " + strings.Join(ss, "; ")
+}
+
+// newError converts the CUE errors into the violations.
+func newError(err error) *Error {
+	var e Error
+	for _, ce := range cueerrors.Errors(err) {
+		format, args := ce.Msg()
+		e.Violations = append(e.Violations, Violation{
+			Path:    strings.Join(ce.Path(), "."),
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	return &e
+}
+
+// decode returns the value of the setting typed as the kind of its field expects.
+// The value is kept as a string if it can't be converted, so the schema reports the mismatch.
+func decode(s string, kind cue.Kind) interface{} {
+	if kind&cue.StringKind != 0 {
+		return s
+	}
+
+	if kind&cue.IntKind != 0 {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+	}
+	if kind&cue.FloatKind != 0 {
+		// The infinities and NaN can't be represented in CUE.
+		if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return f
+		}
+	}
+	if kind&cue.BoolKind != 0 {
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	if kind&(cue.StructKind|cue.ListKind) != 0 && json.Valid([]byte(s)) {
+		// The JSON is decoded by CUE, so the integers aren't turned into floats.
+		return json.RawMessage(s)
+	}
+
+	return s
+}
@@ -0,0 +1,135 @@
+package cueschema
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/google/go-cmp/cmp"
+)
+
+const schema = `
+velocity:     int & >=0 & <=max_velocity
+max_velocity: int | *100
+mode:         "drive" | "park"
+agent_id?:    string
+version?:     =~"^[0-9.]+$"
+ratio?:       float & <=1
+enabled?:     bool
+camera?: fps: int
+`
+
+func TestValidate(t *testing.T) {
+	v, err := New([]byte(schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		in       map[string]string
+		wantPath string
+	}{
+		"valid": {
+			in: map[string]string{"velocity": "5", "max_velocity": "10", "mode": "park", "name": "curiosity"},
+		},
+		"default max": {
+			in: map[string]string{"velocity": "50", "mode": "drive"},
+		},
+		"cross-field": {
+			in:       map[string]string{"velocity": "50", "max_velocity": "10", "mode": "drive"},
+			wantPath: "velocity",
+		},
+		"type": {
+			in:       map[string]string{"velocity": "fast", "mode": "drive"},
+			wantPath: "velocity",
+		},
+		"enum": {
+			in:       map[string]string{"velocity": "5", "mode": "fly"},
+			wantPath: "mode",
+		},
+		"numeric strings": {
+			in: map[string]string{"velocity": "5", "mode": "park", "agent_id": "007", "version": "1.10", "name": "1e3"},
+		},
+		"boolean string": {
+			in: map[string]string{"velocity": "5", "mode": "park", "agent_id": "true"},
+		},
+		"typed": {
+			in: map[string]string{"velocity": "5", "mode": "park", "ratio": "1", "enabled": "true", "camera": `{"fps": 30}`},
+		},
+		"float out of range": {
+			in:       map[string]string{"velocity": "5", "mode": "park", "ratio": "1.5"},
+			wantPath: "ratio",
+		},
+		"infinity": {
+			in:       map[string]string{"velocity": "5", "mode": "park", "ratio": "-inf"},
+			wantPath: "ratio",
+		},
+		"nan": {
+			in:       map[string]string{"velocity": "5", "mode": "park", "ratio": "nan"},
+			wantPath: "ratio",
+		},
+		"invalid boolean": {
+			in:       map[string]string{"velocity": "5", "mode": "park", "enabled": "yes"},
+			wantPath: "enabled",
+		},
+		"nested float": {
+			in:       map[string]string{"velocity": "5", "mode": "park", "camera": `{"fps": 29.97}`},
+			wantPath: "camera.fps",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := v.Validate(tc.in)
+			if tc.wantPath == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("expected *Error got %v", err)
+			}
+			if len(e.Violations) == 0 {
+				t.Fatal("expected violations")
+			}
+			for _, v := range e.Violations {
+				if v.Path != tc.wantPath {
+					t.Errorf("expected violation of %s got %s: %s", tc.wantPath, v.Path, v.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := map[string]struct {
+		in   string
+		kind cue.Kind
+		want interface{}
+	}{
+		"int":              {in: "5", kind: cue.IntKind, want: int64(5)},
+		"int as float":     {in: "5", kind: cue.FloatKind, want: float64(5)},
+		"number":           {in: "0.5", kind: cue.NumberKind, want: 0.5},
+		"infinity":         {in: "inf", kind: cue.NumberKind, want: "inf"},
+		"bool":             {in: "true", kind: cue.BoolKind, want: true},
+		"string":           {in: "007", kind: cue.StringKind, want: "007"},
+		"string or int":    {in: "5", kind: cue.StringKind | cue.IntKind, want: "5"},
+		"invalid int":      {in: "1O", kind: cue.IntKind, want: "1O"},
+		"struct":           {in: `{"fps": 30}`, kind: cue.StructKind, want: json.RawMessage(`{"fps": 30}`)},
+		"list":             {in: `[1, 2]`, kind: cue.ListKind, want: json.RawMessage(`[1, 2]`)},
+		"invalid struct":   {in: "{not json", kind: cue.StructKind, want: "{not json"},
+		"unexpected value": {in: "5", kind: cue.BoolKind, want: "5"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, decode(tc.in, tc.kind)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package dynconf
+
+import "time"
+
+// postpone queues the changes and restarts the debounce timer, see WithUpdateDebounce.
+// The existed flags indicate whether the changes' settings existed before them.
+func (c *Config) postpone(changes []Change, existed []bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending, c.pendingExisted = coalesce(c.pending, c.pendingExisted, changes, existed)
+	if c.debounceTimer != nil {
+		c.debounceTimer.Stop()
+	}
+	c.debounceTimer = time.AfterFunc(c.debounce, c.flush)
+}
+
+// flush calls the update callbacks with the queued changes if there are any.
+func (c *Config) flush() {
+	c.mu.Lock()
+	changes := c.pending
+	c.pending, c.pendingExisted = nil, nil
+	if c.debounceTimer != nil {
+		c.debounceTimer.Stop()
+		c.debounceTimer = nil
+	}
+	c.mu.Unlock()
+
+	if len(changes) != 0 {
+		c.runCallbacks(changes)
+	}
+}
+
+// coalesce appends the changes to the queued ones merging the changes of the same setting,
+// i.e., the merged change has the first old value and the last new value.
+// The queuedExisted and existed flags indicate whether the settings existed before the queued changes and the changes.
+// The merged changes which cancel each other out are dropped,
+// i.e., a setting set back to its old value, or created and then deleted.
+func coalesce(queued []Change, queuedExisted []bool, changes []Change, existed []bool) ([]Change, []bool) {
+	for j, ch := range changes {
+		i := 0
+		for i < len(queued) && queued[i].Setting != ch.Setting {
+			i++
+		}
+		if i == len(queued) {
+			queued = append(queued, ch)
+			queuedExisted = append(queuedExisted, existed[j])
+			continue
+		}
+
+		queued[i].New = ch.New
+		queued[i].Deleted = ch.Deleted
+		created := !queuedExisted[i]
+		if created && queued[i].Deleted || !created && !queued[i].Deleted && queued[i].Old == queued[i].New {
+			queued = append(queued[:i], queued[i+1:]...)
+			queuedExisted = append(queuedExisted[:i], queuedExisted[i+1:]...)
+		}
+	}
+
+	return queued, queuedExisted
+}
@@ -0,0 +1,140 @@
+package dynconf
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/google/go-cmp/cmp"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestUpdateDebounce(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		got  [][]Change
+		hits = make(chan struct{}, 10)
+	)
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New(
+		"/configs/curiosity/",
+		WithLogger(logger),
+		WithUpdateDebounce(50*time.Millisecond),
+		WithOnChanges(func(changes []Change) {
+			mu.Lock()
+			got = append(got, changes)
+			mu.Unlock()
+			hits <- struct{}{}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for i, v := range []string{"1", "2", "3"} {
+		c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+			putEvent("/configs/curiosity/velocity", v, int64(i+2)),
+		}, int64(i+2)))
+	}
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+		putEvent("/configs/curiosity/name", "curiosity", 5),
+	}, 5))
+
+	select {
+	case <-hits:
+	case <-time.After(time.Second):
+		t.Fatal("expected callback")
+	}
+	select {
+	case <-hits:
+		t.Fatal("expected a single callback")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	want := [][]Change{{
+		{Setting: "velocity", New: "3"},
+		{Setting: "name", New: "curiosity"},
+	}}
+	mu.Lock()
+	defer mu.Unlock()
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	tests := map[string]struct {
+		queued        []Change
+		queuedExisted []bool
+		changes       []Change
+		existed       []bool
+		want          []Change
+		wantExisted   []bool
+	}{
+		"merged": {
+			queued:        []Change{{Setting: "velocity", Old: "1", New: "2"}},
+			queuedExisted: []bool{true},
+			changes: []Change{
+				{Setting: "name", New: "curiosity"},
+				{Setting: "velocity", Old: "2", Deleted: true},
+			},
+			existed: []bool{false, true},
+			want: []Change{
+				{Setting: "velocity", Old: "1", Deleted: true},
+				{Setting: "name", New: "curiosity"},
+			},
+			wantExisted: []bool{true, false},
+		},
+		"set back": {
+			queued:        []Change{{Setting: "velocity", Old: "1", New: "2"}, {Setting: "name", New: "curiosity"}},
+			queuedExisted: []bool{true, false},
+			changes:       []Change{{Setting: "velocity", Old: "2", New: "1"}},
+			existed:       []bool{true},
+			want:          []Change{{Setting: "name", New: "curiosity"}},
+			wantExisted:   []bool{false},
+		},
+		"deleted and created again": {
+			queued:        []Change{{Setting: "velocity", Old: "1", Deleted: true}},
+			queuedExisted: []bool{true},
+			changes:       []Change{{Setting: "velocity", New: "1"}},
+			existed:       []bool{false},
+			want:          []Change{},
+			wantExisted:   []bool{},
+		},
+		"created and deleted": {
+			queued:        []Change{{Setting: "name", New: "curiosity"}},
+			queuedExisted: []bool{false},
+			changes:       []Change{{Setting: "name", Old: "curiosity", Deleted: true}},
+			existed:       []bool{true},
+			want:          []Change{},
+			wantExisted:   []bool{},
+		},
+		"created empty": {
+			queued:        []Change{{Setting: "name", New: "curiosity"}},
+			queuedExisted: []bool{false},
+			changes:       []Change{{Setting: "name", Old: "curiosity"}},
+			existed:       []bool{true},
+			want:          []Change{{Setting: "name"}},
+			wantExisted:   []bool{false},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, gotExisted := coalesce(tc.queued, tc.queuedExisted, tc.changes, tc.existed)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(tc.wantExisted, gotExisted); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
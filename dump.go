@@ -0,0 +1,93 @@
+package dynconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MarshalJSON encodes the current settings as a JSON object, e.g., for debug endpoints.
+// The values are masked if WithMaskedValues option was used.
+func (c *Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.snapshot())
+}
+
+// WriteTo writes the current settings to w as a YAML mapping sorted by the setting names, e.g., for support bundles.
+// The values are masked if WithMaskedValues option was used.
+func (c *Config) WriteTo(w io.Writer) (int64, error) {
+	b, err := yaml.Marshal(c.snapshot())
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// WriteEnv writes the current settings to w as the environment variables with the prefix sorted by their names,
+// i.e., the format of NewEnvSource where velocity setting is written as CURIOSITY_VELOCITY=5 if the prefix is CURIOSITY_.
+// The characters which aren't allowed in the variable names are replaced with underscores,
+// and the values are quoted when necessary.
+// The values are masked if WithMaskedValues option was used.
+func (c *Config) WriteEnv(w io.Writer, prefix string) (int64, error) {
+	ss := c.snapshot()
+	keys := make([]string, 0, len(ss))
+	for k := range ss {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		buf.WriteString(prefix)
+		buf.WriteString(envName(k))
+		buf.WriteByte('=')
+		buf.WriteString(envValue(ss[k]))
+		buf.WriteByte('\n')
+	}
+
+	return buf.WriteTo(w)
+}
+
+// snapshot returns all the settings with their values masked if necessary.
+func (c *Config) snapshot() map[string]string {
+	ss := c.Settings()
+	if ss == nil {
+		return map[string]string{}
+	}
+	if c.maskValues {
+		for k := range ss {
+			ss[k] = maskedValue
+		}
+	}
+
+	return ss
+}
+
+// envName returns the uppercase setting name where the characters other than letters, digits, and underscores are replaced.
+func envName(setting string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, setting)
+}
+
+// envValue quotes the value if it has the characters interpreted by shells.
+func envValue(value string) string {
+	if strings.ContainsAny(value, " \t\r\n\"'\\$`#;&|<>(){}*?!~") {
+		return strconv.Quote(value)
+	}
+
+	return value
+}
@@ -0,0 +1,70 @@
+package dynconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/go-kit/log"
+)
+
+func TestConfigDump(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != "{}" {
+		t.Errorf("expected empty object got %s", got)
+	}
+
+	c.settings.Store("velocity", "5")
+	c.settings.Store("camera.name", "front hazcam")
+
+	if b, err = json.Marshal(c); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"camera.name":"front hazcam","velocity":"5"}`
+	if got := string(b); got != want {
+		t.Errorf("expected %s got %s", want, got)
+	}
+
+	var buf bytes.Buffer
+	n, err := c.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "camera.name: front hazcam\nvelocity: \"5\"\n"
+	if got := buf.String(); got != want || n != int64(len(want)) {
+		t.Errorf("expected %q got %q (%d bytes)", want, got, n)
+	}
+
+	buf.Reset()
+	if _, err = c.WriteEnv(&buf, "CURIOSITY_"); err != nil {
+		t.Fatal(err)
+	}
+	want = "CURIOSITY_CAMERA_NAME=\"front hazcam\"\nCURIOSITY_VELOCITY=5\n"
+	if got := buf.String(); got != want {
+		t.Errorf("expected %q got %q", want, got)
+	}
+
+	c.maskValues = true
+	if b, err = json.Marshal(c); err != nil {
+		t.Fatal(err)
+	}
+	want = `{"camera.name":"******","velocity":"******"}`
+	if got := string(b); got != want {
+		t.Errorf("expected %s got %s", want, got)
+	}
+}
@@ -0,0 +1,189 @@
+// Package dynamosource provides a dynconf.Source backed by a DynamoDB table,
+// for teams standardized on AWS-managed datastores.
+package dynamosource
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pooyakn/dynconf"
+)
+
+const (
+	// pathKey is the partition key holding the path to a project's config, e.g., /configs/curiosity/.
+	pathKey = "path"
+	// settingKey is the sort key holding the setting name.
+	settingKey = "setting"
+	// valueKey is the attribute holding the setting value.
+	valueKey = "value"
+)
+
+// DefaultInterval is how often the table is queried if the interval passed to New isn't positive.
+const DefaultInterval = time.Minute
+
+// Source is a dynconf.Source which keeps the settings as items of a DynamoDB table.
+// The items of a project's config share the partition key "path", the sort key "setting" is the setting name,
+// and the attribute "value" is its value which can be a string, number, or boolean.
+// For example, velocity setting of project Curiosity is stored as the following item:
+// {"path": "/configs/curiosity/", "setting": "velocity", "value": 5}.
+//
+// The settings are polled with strongly consistent queries,
+// and the Source counts the updates to use them as revisions.
+type Source struct {
+	client   dynamodb.QueryAPIClient
+	table    string
+	path     string
+	interval time.Duration
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// settings are the last known settings used to detect the changes.
+	settings map[string]string
+	// rev is the number of updates.
+	rev int64
+}
+
+// New returns a Source which queries the table for the items of the path every interval,
+// or every DefaultInterval if interval isn't positive.
+func New(client dynamodb.QueryAPIClient, table, path string, interval time.Duration) *Source {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Source{
+		client:   client,
+		table:    table,
+		path:     path,
+		interval: interval,
+		settings: make(map[string]string),
+	}
+}
+
+// Load queries the items of the path.
+func (s *Source) Load(ctx context.Context) (map[string]string, int64, error) {
+	settings, err := s.fetch(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = make(map[string]string, len(settings))
+	for k, v := range settings {
+		s.settings[k] = v
+	}
+	s.rev++
+
+	return settings, s.rev, nil
+}
+
+// Watch polls the items and reports the settings' changes until ctx is canceled.
+func (s *Source) Watch(ctx context.Context) <-chan dynconf.SourceUpdate {
+	out := make(chan dynconf.SourceUpdate)
+	go func() {
+		defer close(out)
+
+		t := time.NewTicker(s.interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				return
+			}
+
+			var u dynconf.SourceUpdate
+			settings, err := s.fetch(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			s.mu.Lock()
+			if err != nil {
+				u.Err = err
+			} else {
+				u.Events = dynconf.DiffSettings(s.settings, settings)
+				if len(u.Events) != 0 {
+					s.settings = settings
+					s.rev++
+				}
+			}
+			u.Revision = s.rev
+			s.mu.Unlock()
+
+			select {
+			case out <- u:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Ping queries the items without reporting their changes, so they're still reported by Watch.
+func (s *Source) Ping(ctx context.Context) error {
+	_, err := s.fetch(ctx)
+	return err
+}
+
+// Close does nothing since the DynamoDB client doesn't need to be closed.
+func (s *Source) Close() error {
+	return nil
+}
+
+// fetch returns all the settings of the path.
+func (s *Source) fetch(ctx context.Context) (map[string]string, error) {
+	p := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+		TableName:                aws.String(s.table),
+		KeyConditionExpression:   aws.String("#path = :path"),
+		ExpressionAttributeNames: map[string]string{"#path": pathKey},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":path": &types.AttributeValueMemberS{Value: s.path},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+
+	settings := make(map[string]string)
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			setting, ok := item[settingKey].(*types.AttributeValueMemberS)
+			if !ok {
+				return nil, fmt.Errorf("invalid item of %s: %s must be a string", s.path, settingKey)
+			}
+			value, err := attributeValue(item[valueKey])
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting %s: %w", setting.Value, err)
+			}
+			settings[setting.Value] = value
+		}
+	}
+
+	return settings, nil
+}
+
+// attributeValue converts the DynamoDB attribute to the setting's value.
+func attributeValue(av types.AttributeValue) (string, error) {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return v.Value, nil
+	case *types.AttributeValueMemberN:
+		return v.Value, nil
+	case *types.AttributeValueMemberBOOL:
+		return strconv.FormatBool(v.Value), nil
+	case nil:
+		return "", fmt.Errorf("%s is missing", valueKey)
+	default:
+		return "", fmt.Errorf("unsupported %s type %T", valueKey, av)
+	}
+}
@@ -0,0 +1,144 @@
+package dynamosource
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/go-kit/log"
+	"github.com/pooyakn/dynconf"
+)
+
+// fakeTable serves the items of a single path, one item per page.
+type fakeTable struct {
+	mu    sync.Mutex
+	items []map[string]types.AttributeValue
+}
+
+func (f *fakeTable) Query(ctx context.Context, in *dynamodb.QueryInput, opts ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i := 0
+	if in.ExclusiveStartKey != nil {
+		for i < len(f.items) && f.items[i][settingKey].(*types.AttributeValueMemberS).Value != in.ExclusiveStartKey[settingKey].(*types.AttributeValueMemberS).Value {
+			i++
+		}
+		i++
+	}
+	out := dynamodb.QueryOutput{}
+	if i < len(f.items) {
+		out.Items = f.items[i : i+1]
+		if i+1 < len(f.items) {
+			out.LastEvaluatedKey = f.items[i]
+		}
+	}
+
+	return &out, nil
+}
+
+func (f *fakeTable) set(items ...map[string]types.AttributeValue) {
+	f.mu.Lock()
+	f.items = items
+	f.mu.Unlock()
+}
+
+func item(setting string, value types.AttributeValue) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		pathKey:    &types.AttributeValueMemberS{Value: "/configs/curiosity/"},
+		settingKey: &types.AttributeValueMemberS{Value: setting},
+		valueKey:   value,
+	}
+}
+
+func TestSource(t *testing.T) {
+	table := fakeTable{}
+	table.set(
+		item("velocity", &types.AttributeValueMemberN{Value: "5"}),
+		item("is_camera_enabled", &types.AttributeValueMemberBOOL{Value: true}),
+	)
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	src := New(&table, "configs", "/configs/curiosity/", 10*time.Millisecond)
+	c, err := dynconf.New("/configs/curiosity/", dynconf.WithLogger(logger), dynconf.WithSource(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = c.Ready(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Integer("velocity", 0); got != 5 {
+		t.Errorf("expected velocity 5 got %d", got)
+	}
+	if got := c.Boolean("is_camera_enabled", false); !got {
+		t.Error("expected camera to be enabled")
+	}
+
+	table.set(item("name", &types.AttributeValueMemberS{Value: "curiosity"}))
+	err = c.AwaitFunc(ctx, func(settings map[string]string) bool {
+		return len(settings) == 1 && settings["name"] == "curiosity"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAttributeValue(t *testing.T) {
+	tests := map[string]struct {
+		in      types.AttributeValue
+		want    string
+		wantErr bool
+	}{
+		"string": {
+			in:   &types.AttributeValueMemberS{Value: "curiosity"},
+			want: "curiosity",
+		},
+		"number": {
+			in:   &types.AttributeValueMemberN{Value: "5.5"},
+			want: "5.5",
+		},
+		"bool": {
+			in:   &types.AttributeValueMemberBOOL{Value: false},
+			want: "false",
+		},
+		"missing": {
+			wantErr: true,
+		},
+		"list": {
+			in:      &types.AttributeValueMemberL{},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := attributeValue(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %t got %v", tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %q got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestNewInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if got := New(nil, "configs", "/configs/curiosity/", interval).interval; got != DefaultInterval {
+			t.Errorf("expected interval %v for %v got %v", DefaultInterval, interval, got)
+		}
+	}
+}
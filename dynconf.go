@@ -1,19 +1,37 @@
-// Package dynconf provides a dynamic configuration backed by etcd.
+// Package dynconf provides a dynamic configuration backed by etcd (or another Source).
 // It can be used to access your project's settings without redeploying it every time a value changes.
 package dynconf
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"github.com/BurntSushi/toml"
+	"github.com/coreos/go-semver/semver"
 	"github.com/go-kit/log"
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"github.com/shopspring/decimal"
+	"github.com/vmihailenco/msgpack/v5"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"gopkg.in/yaml.v3"
 )
 
 // Option sets up a Config.
@@ -33,23 +51,384 @@ func WithLogger(logger log.Logger) Option {
 	}
 }
 
-// WithOnUpdate sets a function to be called when a setting is updated.
+// WithOnUpdate sets a function to be called with all the settings when a setting is updated,
+// see WithOnChanges to receive only the changed settings.
 func WithOnUpdate(f func(settings map[string]string)) Option {
 	return func(c *Config) {
 		c.onUpdate = f
 	}
 }
 
+// WithOnChanges sets a function to be called with the changes of the settings
+// which were updated or deleted together, e.g., in an etcd watch response,
+// so the application doesn't have to diff all the settings itself.
+// The changes are in the order they happened.
+func WithOnChanges(f func(changes []Change)) Option {
+	return func(c *Config) {
+		c.onChanges = f
+	}
+}
+
+// WithOnDelete sets a function to be called when a setting is deleted,
+// e.g., to explicitly revert to the default when an operator removes a setting.
+func WithOnDelete(f func(setting string)) Option {
+	return func(c *Config) {
+		c.onDelete = f
+	}
+}
+
+// WithUpdateDebounce delays the WithOnUpdate, WithOnChanges, and WithOnDelete callbacks
+// until no setting has changed for d, so a burst of changes, e.g., a bulk import, triggers them once.
+// The changes of the same setting are coalesced, i.e., the callbacks see its first old and last new value,
+// and the changes which cancel each other out, e.g., a setting created and then deleted, aren't reported.
+func WithUpdateDebounce(d time.Duration) Option {
+	return func(c *Config) {
+		c.debounce = d
+	}
+}
+
+// WithAsyncCallbacks runs the WithOnUpdate, WithOnChanges, and WithOnDelete callbacks
+// in a pool of the given number of workers, so slow callbacks don't hold back the settings updates.
+// Note, the callbacks of different updates might run concurrently and out of order if there are several workers,
+// and the callbacks which haven't started when the Config is closed are dropped.
+func WithAsyncCallbacks(workers int) Option {
+	return func(c *Config) {
+		c.workers = workers
+	}
+}
+
+// WithSchema makes Config reject the settings' values which don't satisfy the schema,
+// so a typo in a value can't change the application's behavior, e.g., velocity set to "1O".
+// The setting keeps its last valid value, or it's treated as not found if it had none,
+// and onInvalid is called with the rejected value unless it's nil.
+func WithSchema(schema *Schema, onInvalid func(setting, value string, err error)) Option {
+	return func(c *Config) {
+		c.schema = schema
+		c.onInvalid = onInvalid
+	}
+}
+
+// WithValidator makes Config validate all the settings together whenever they're loaded or updated,
+// e.g., to check the rules spanning several settings.
+// The settings which don't pass the validator are rejected as a whole, i.e., the previous valid settings are kept,
+// and onInvalid is called with the validator's error unless it's nil.
+// Note, only the cached settings are validated if WithMaxKeys is set.
+func WithValidator(v Validator, onInvalid func(err error)) Option {
+	return func(c *Config) {
+		c.validator = v
+		c.onInvalidSettings = onInvalid
+	}
+}
+
+// WithDryRun makes the write methods such as Set, SetBatch and Delete validate and log the changes
+// without writing them to etcd. The changes are also checked against WithSchema and WithValidator,
+// i.e., a write which the Config would reject returns an error.
+func WithDryRun() Option {
+	return func(c *Config) {
+		c.dryRun = true
+	}
+}
+
+// WithEnvExpansion replaces ${var} or $var in the settings' values
+// according to the values of the process environment variables.
+// Unknown variables are replaced by the empty string.
+//
+// Note, anyone who can write to etcd would be able to read the service's environment variables
+// through the settings, so make sure the secrets aren't exposed this way.
+func WithEnvExpansion() Option {
+	return func(c *Config) {
+		c.expandEnv = true
+	}
+}
+
+// WithNormalizeWeights makes WeightedFloats scale the weights so they sum up to 1.
+func WithNormalizeWeights() Option {
+	return func(c *Config) {
+		c.normalizeWeights = true
+	}
+}
+
+// WithCronSeconds makes Cron getters accept expressions with an optional seconds field,
+// e.g., "*/30 * * * * *", in addition to the standard 5-field expressions.
+func WithCronSeconds() Option {
+	return func(c *Config) {
+		c.cronParser = cron.NewParser(
+			cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+		)
+	}
+}
+
+// WithRequireKeys makes New wait for the settings to be loaded from etcd
+// and fail if any of the given settings is absent.
+// The wait is bounded by the startup timeout, see WithStartupTimeout.
+func WithRequireKeys(keys ...string) Option {
+	return func(c *Config) {
+		c.requiredKeys = keys
+	}
+}
+
+// WithStartupTimeout sets how long New waits for the settings to be loaded
+// when the required settings are set with WithRequireKeys. By default it's 5 seconds.
+func WithStartupTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.startupTimeout = d
+	}
+}
+
+// WithBootstrapFile sets a JSON file with the settings to start with until they're loaded from etcd,
+// e.g., the last known good settings persisted with WithPersistOnClose.
+// A missing or invalid file is logged and ignored.
+func WithBootstrapFile(path string) Option {
+	return func(c *Config) {
+		c.bootstrapFile = path
+	}
+}
+
+// WithPersistOnClose makes Close write the current settings to the given JSON file,
+// so they can be used with WithBootstrapFile when etcd is unavailable at boot.
+func WithPersistOnClose(path string) Option {
+	return func(c *Config) {
+		c.persistFile = path
+	}
+}
+
+// WithMaxKeys limits the number of settings kept in memory for paths with a huge number of keys.
+// Instead of loading all the settings at once, a setting is fetched from etcd when it's read for the first time
+// and cached until it's evicted as the least recently used one.
+// The settings which aren't in etcd are cached as absent as well, so reading them doesn't hit etcd every time.
+// Only the cached settings are watched, each from the revision it was fetched at,
+// and Settings returns the cached settings only.
+func WithMaxKeys(n int) Option {
+	return func(c *Config) {
+		c.maxKeys = n
+	}
+}
+
+// WithResolver registers a function that resolves the setting values of the given scheme,
+// e.g., values such as "secretref://aws/prod/db-password" are resolved by the "secretref" scheme resolver.
+// The resolved values are returned by ResolvedString.
+func WithResolver(scheme string, resolve func(ctx context.Context, ref string) (string, error)) Option {
+	return func(c *Config) {
+		c.resolvers[scheme] = resolve
+	}
+}
+
+// WithDecoder registers a function that decodes the values of the given format for StructAs,
+// e.g., WithDecoder("cue", decodeCUE). The json, yaml, toml, msgpack, and proto formats
+// are registered by default, and registering the json decoder replaces encoding/json in Struct.
+func WithDecoder(format string, decode func(data []byte, out interface{}) error) Option {
+	return func(c *Config) {
+		c.decoders[format] = decode
+	}
+}
+
+// WithCanonicalWrites makes Set and SetBatch store the boolean and numeric values in the canonical form,
+// e.g., "true" rather than "TRUE" and "10" rather than "010".
+// Every value that parses as a boolean or a number is rewritten, so a zero-padded string such as "0899"
+// is stored as "899", and the values already in etcd are read as is.
+func WithCanonicalWrites() Option {
+	return func(c *Config) {
+		c.canonicalWrites = true
+	}
+}
+
+// WithReadTimeout bounds how long the Context getters such as StringContext wait for the settings to be loaded
+// when the given context has no deadline.
+func WithReadTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.readTimeout = d
+	}
+}
+
+// WithSortedArrays makes StringArray, IntegerArray, Int64Array, UintArray and FloatArray
+// return their elements in ascending order regardless of the order they're stored in.
+// By default the stored order is preserved.
+func WithSortedArrays() Option {
+	return func(c *Config) {
+		c.sortArrays = true
+	}
+}
+
+// WithRejectOutOfRange makes IntegerInRange and FloatInRange return the default value
+// when a setting is out of range instead of clamping it to the range.
+func WithRejectOutOfRange() Option {
+	return func(c *Config) {
+		c.rejectOutOfRange = true
+	}
+}
+
+// WithSource sets the store the settings are loaded from instead of etcd.
+// Note, the operations that write to etcd, Lag, and WithMaxKeys are only available
+// when the etcd client is set with WithEtcdClient.
+func WithSource(s Source) Option {
+	return func(c *Config) {
+		c.source = s
+	}
+}
+
+// WithMaskedValues hides the settings' values in logs, e.g., when the settings hold credentials.
+func WithMaskedValues() Option {
+	return func(c *Config) {
+		c.maskValues = true
+	}
+}
+
+// WithAbsoluteURLs makes URL and URLRequired reject the URLs without a scheme or host,
+// e.g., "localhost:8080" or "/api", which url.Parse accepts.
+func WithAbsoluteURLs() Option {
+	return func(c *Config) {
+		c.absoluteURLs = true
+	}
+}
+
+// WithStructFormatDetection makes Struct detect the format of the values,
+// so the binary values which aren't valid UTF-8 are decoded as MessagePack,
+// and the text values which aren't valid JSON are decoded as YAML. By default only JSON is decoded.
+func WithStructFormatDetection() Option {
+	return func(c *Config) {
+		c.detectStructFormat = true
+	}
+}
+
+// WithOnRawEvent sets a function to be called with the etcd events of every watch response
+// before they're applied to the settings. The events carry the previous key-values, i.e., PrevKv,
+// of the overwritten and deleted keys.
+// It's a low-level hook for advanced use cases such as tracking leases or revisions,
+// the events must not be modified.
+func WithOnRawEvent(f func(events []*clientv3.Event)) Option {
+	return func(c *Config) {
+		c.onRawEvent = f
+	}
+}
+
 // Config provides access to a project's settings stored in etcd.
 type Config struct {
 	// path (etcd key prefix) is the path to the project's config where settings are stored.
 	path string
 	// settings map holds the project's settings obtained from etcd.
 	settings *sync.Map
-	etcd     *clientv3.Client
+	// defaults map holds the settings' default values registered with SetDefault.
+	defaults *sync.Map
+	// overrides map holds the settings' values set with command-line flags, see BindFlagSet.
+	overrides *sync.Map
+	etcd      *clientv3.Client
+	// source is the store the settings are loaded from, etcd by default.
+	source Source
+	// cancel stops watching the source.
+	cancel   context.CancelFunc
 	logger   log.Logger
 	onUpdate func(settings map[string]string)
-	ready    chan struct{}
+	// onChanges is called with the changes of each update.
+	onChanges func(changes []Change)
+	// onDelete is called with the name of each deleted setting.
+	onDelete func(setting string)
+	// schema validates the settings' values, see WithSchema.
+	schema *Schema
+	// onInvalid is called with the values rejected by the schema.
+	onInvalid func(setting, value string, err error)
+	// validator validates all the settings together, see WithValidator.
+	validator Validator
+	// onInvalidSettings is called with the validator's errors.
+	onInvalidSettings func(err error)
+	// debounce is how long the callbacks are delayed after the last change.
+	debounce time.Duration
+	// workers is the number of goroutines running the callbacks, zero means the callbacks are run synchronously.
+	workers int
+	// jobs are the callbacks waiting for the workers.
+	jobs chan func()
+	// onRawEvent is called with the etcd events before they're applied.
+	onRawEvent func(events []*clientv3.Event)
+	// ready is closed when the settings are loaded from etcd.
+	ready     chan struct{}
+	readyOnce sync.Once
+	// requiredKeys are the settings that must be present in etcd when Config is created.
+	requiredKeys []string
+	// startupTimeout is how long New waits for the required settings.
+	startupTimeout time.Duration
+	// bootstrapFile is the JSON file to load the initial settings from.
+	bootstrapFile string
+	// persistFile is the JSON file to write the settings to on Close.
+	persistFile string
+	// maxKeys is the max number of cached settings, zero means all the settings are loaded.
+	maxKeys int
+	// lru tracks the cached settings' usage when maxKeys is set.
+	lru *lru
+	// lazyMu serializes caching the fetched settings with applying the watch updates, see cacheFetched.
+	lazyMu sync.Mutex
+	// readTimeout bounds the wait for the settings in the Context getters.
+	readTimeout time.Duration
+	// canonicalWrites indicates that boolean and numeric values should be written in the canonical form.
+	canonicalWrites bool
+	// dryRun indicates that the changes shouldn't be written to etcd.
+	dryRun bool
+	// expandEnv indicates that environment variables should be expanded in the settings' values.
+	expandEnv bool
+	// cronParser validates the cron expressions.
+	cronParser cron.Parser
+	// rejectOutOfRange indicates that the out of range values should be replaced by the default rather than clamped.
+	rejectOutOfRange bool
+	// sortArrays indicates that the arrays should be sorted.
+	sortArrays bool
+	// normalizeWeights indicates that WeightedFloats should scale the weights to sum up to 1.
+	normalizeWeights bool
+	// maskValues indicates that the settings' values shouldn't be logged.
+	maskValues bool
+	// absoluteURLs indicates that the URLs must have a scheme and host.
+	absoluteURLs bool
+	// detectStructFormat indicates that Struct should detect the format of the values.
+	detectStructFormat bool
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// revision is the latest etcd revision applied to the settings.
+	revision int64
+	// revisionAt is the time when the revision was applied.
+	revisionAt time.Time
+	// connected indicates that the last etcd request or watch response succeeded, and the connection didn't fail since.
+	connected bool
+	// stateChanged is closed and replaced when connected changes.
+	stateChanged chan struct{}
+	// lastErr is the last etcd error.
+	lastErr error
+	// updatedAt is the time when the settings were last updated.
+	updatedAt time.Time
+	// updated is closed and replaced when the settings are updated.
+	updated chan struct{}
+	// pending are the changes waiting for the debounce timer.
+	pending []Change
+	// pendingExisted indicates whether the settings of the pending changes existed before them.
+	pendingExisted []bool
+	// debounceTimer fires when the settings have settled, see WithUpdateDebounce.
+	debounceTimer *time.Timer
+	// resolvers resolve the setting values by their scheme, see WithResolver.
+	resolvers map[string]func(ctx context.Context, ref string) (string, error)
+	// decoders decode the struct values by their format, see WithDecoder.
+	decoders map[string]func(data []byte, out interface{}) error
+	// resolved map caches the resolved values by their references.
+	resolved *sync.Map
+	// changedAt map holds the time when each setting was last changed.
+	changedAt *sync.Map
+	// now returns the current time, it's replaced in tests.
+	now func() time.Time
+	// reads map counts how many times each setting was read by getters.
+	reads *sync.Map
+	// tlsConfigs caches the TLS configs built by TLSConfig.
+	tlsConfigs *sync.Map
+	// regexps caches the patterns compiled by Regexp.
+	regexps *sync.Map
+	// templates caches the templates parsed by Template.
+	templates *sync.Map
+	// listeners are notified about the settings' changes.
+	listeners      map[int]func([]Change)
+	nextListenerID int
+	// eventListeners are notified about the settings' changes with their revisions, see Events.
+	eventListeners map[int]func([]Event)
+	// done is closed when the Config is closed.
+	done <-chan struct{}
+	// watchCtx is canceled when the Config is closed, the cached settings' watches are derived from it.
+	watchCtx context.Context
+	// keyWatches stop watching the cached settings by their names when WithMaxKeys is set, guarded by lazyMu.
+	keyWatches map[string]context.CancelFunc
 }
 
 // New returns a Config which can be set up with Option functions.
@@ -61,101 +440,514 @@ type Config struct {
 // /configs/curiosity/velocity and /configs/curiosity/is_camera_enabled.
 func New(path string, options ...Option) (*Config, error) {
 	c := Config{
-		path:     path,
-		settings: &sync.Map{},
-		logger:   log.NewNopLogger(),
-		ready:    make(chan struct{}, 1),
+		path:           path,
+		settings:       &sync.Map{},
+		defaults:       &sync.Map{},
+		overrides:      &sync.Map{},
+		tlsConfigs:     &sync.Map{},
+		regexps:        &sync.Map{},
+		templates:      &sync.Map{},
+		reads:          &sync.Map{},
+		changedAt:      &sync.Map{},
+		now:            time.Now,
+		resolvers:      make(map[string]func(ctx context.Context, ref string) (string, error)),
+		resolved:       &sync.Map{},
+		logger:         log.NewNopLogger(),
+		ready:          make(chan struct{}),
+		startupTimeout: 5 * time.Second,
+		updated:        make(chan struct{}),
+		stateChanged:   make(chan struct{}),
+		listeners:      make(map[int]func([]Change)),
+		eventListeners: make(map[int]func([]Event)),
+		decoders: map[string]func(data []byte, out interface{}) error{
+			"json":    unmarshalJSON,
+			"yaml":    yaml.Unmarshal,
+			"toml":    toml.Unmarshal,
+			"msgpack": msgpack.Unmarshal,
+			"proto":   unmarshalProto,
+		},
+		cronParser: cron.NewParser(
+			cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+		),
 	}
 	for _, opt := range options {
 		opt(&c)
 	}
+	if c.maskValues {
+		c.logger = maskedLogger{c.logger}
+	}
 
-	if c.etcd == nil {
+	if c.source == nil && c.etcd == nil {
 		var err error
 		c.etcd, err = clientv3.New(clientv3.Config{
 			Endpoints: []string{"127.0.0.1:2379"},
+			// The keepalive pings detect the dead connections, see etcdSource.watchConnection.
+			DialKeepAliveTime:    keepAliveTime,
+			DialKeepAliveTimeout: keepAliveTimeout,
 		})
 		if err != nil {
 			return nil, err
 		}
 	}
-	go c.watch()
+	if c.bootstrapFile != "" {
+		if err := c.loadFile(c.bootstrapFile); err != nil {
+			c.logger.Log("msg", "dynconf failed to load bootstrap file", "path", c.path, "file", c.bootstrapFile, "err", err)
+		}
+	}
+	if c.source == nil {
+		c.source = &etcdSource{client: c.etcd, path: c.path}
+	}
+	if c.maxKeys > 0 {
+		if c.etcd != nil {
+			c.lru = newLRU(c.maxKeys)
+			c.keyWatches = make(map[string]context.CancelFunc)
+		} else {
+			c.logger.Log("msg", "dynconf max keys require etcd, all the settings are loaded", "path", c.path)
+		}
+	}
+	var ctx context.Context
+	ctx, c.cancel = context.WithCancel(context.Background())
+	c.done = ctx.Done()
+	c.watchCtx = ctx
+	if c.workers > 0 {
+		c.startWorkers(ctx)
+	}
+	go c.watch(ctx)
+
+	if len(c.requiredKeys) != 0 {
+		if err := c.checkRequiredKeys(); err != nil {
+			c.cancel()
+			c.source.Close()
+			return nil, err
+		}
+	}
 
 	return &c, nil
 }
 
-// Ready waits until the Config is ready to use.
+// checkRequiredKeys waits for the settings to be loaded and
+// returns an error naming the required settings that are absent.
+func (c *Config) checkRequiredKeys() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.startupTimeout)
+	defer cancel()
+	if err := c.Ready(ctx); err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, k := range c.requiredKeys {
+		if !c.present(k) {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("dynconf required settings not found: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// Ready waits until the Config is ready to use, i.e., the settings were loaded from etcd (or the source).
 func (c *Config) Ready(ctx context.Context) error {
 	select {
 	case <-c.ready:
-		close(c.ready)
 		return nil
 	case <-ctx.Done():
 		return fmt.Errorf("dynconf not ready: %w", ctx.Err())
 	}
 }
 
-// Close closes the underlying etcd client.
+// Ping checks that etcd is reachable by fetching at most one key under the path.
+// Unlike Ready, it doesn't tell whether the settings were loaded.
+// When Config is backed by another Source, Ping calls its Ping method if it implements Pinger,
+// otherwise it reports the last error of loading or watching the source, see Status.
+func (c *Config) Ping(ctx context.Context) error {
+	if c.etcd == nil {
+		if p, ok := c.source.(Pinger); ok {
+			if err := p.Ping(ctx); err != nil {
+				return fmt.Errorf("dynconf source is unreachable: %w", err)
+			}
+			return nil
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		switch {
+		case c.connected:
+			return nil
+		case c.lastErr != nil:
+			return fmt.Errorf("dynconf source is unreachable: %w", c.lastErr)
+		default:
+			return errors.New("dynconf source is not loaded yet")
+		}
+	}
+	if _, err := c.etcd.Get(ctx, c.path, clientv3.WithPrefix(), clientv3.WithLimit(1), clientv3.WithKeysOnly()); err != nil {
+		return fmt.Errorf("dynconf etcd is unreachable: %w", err)
+	}
+
+	return nil
+}
+
+// Close stops watching and closes the underlying etcd client (or the source).
+// If WithPersistOnClose is set, the settings are written to the file beforehand.
 func (c *Config) Close() error {
-	return c.etcd.Close()
+	if c.persistFile != "" {
+		if err := c.saveFile(c.persistFile); err != nil {
+			c.logger.Log("msg", "dynconf failed to persist settings", "path", c.path, "file", c.persistFile, "err", err)
+		}
+	}
+
+	c.cancel()
+	c.flush()
+	return c.source.Close()
 }
 
-// load fetches all the settings from etcd for the configured path.
-func (c *Config) load() error {
-	r, err := c.etcd.Get(context.Background(), c.path, clientv3.WithPrefix())
+// load fetches all the settings from the source.
+func (c *Config) load(ctx context.Context) error {
+	loaded, rev, err := c.source.Load(ctx)
 	if err != nil {
 		return err
 	}
 
-	// prefixLen is the length of the key prefix (path) in etcd to extract a setting name.
-	prefixLen := len(c.path)
-	for i := 0; i < len(r.Kvs); i++ {
-		setting := string(r.Kvs[i].Key)
-		setting = setting[prefixLen:]
-
-		c.settings.Store(
-			setting,
-			string(r.Kvs[i].Value),
-		)
+	settings := make(map[string]string, len(loaded))
+	for setting, value := range loaded {
+		value = c.expand(setting, value)
+		if c.valid(setting, value) {
+			settings[setting] = value
+		}
+	}
+	// The settings rejected by the validator are discarded, and the previous ones are kept.
+	if c.validSnapshot(settings) {
+		now := c.now()
+		for setting, value := range settings {
+			c.settings.Store(setting, value)
+			c.changedAt.Store(setting, now)
+		}
+		// The settings from the bootstrap file that are absent in the source are dropped.
+		c.settings.Range(func(key interface{}, value interface{}) bool {
+			k, _ := key.(string)
+			if _, ok := loaded[k]; !ok {
+				c.settings.Delete(key)
+			}
+			return true
+		})
 	}
+	c.setState(nil)
+	c.setRevision(rev)
+	c.notify()
 
-	c.ready <- struct{}{}
+	c.markReady()
 
 	return nil
 }
 
-// watch watches for the settings' changes in etcd and
-// updates the in-memory settings cache.
-func (c *Config) watch() {
-	if err := c.load(); err != nil {
+// markReady signals that the Config is ready to use.
+func (c *Config) markReady() {
+	c.readyOnce.Do(func() {
+		close(c.ready)
+	})
+}
+
+// watch watches for the settings' changes in the source and
+// updates the in-memory settings cache until ctx is canceled.
+func (c *Config) watch(ctx context.Context) {
+	if c.lru != nil {
+		// The settings are fetched lazily, so there is nothing to wait for,
+		// and they're watched one by one once cached, see watchKey.
+		c.markReady()
+		src := etcdSource{client: c.etcd, path: c.path}
+		src.watchConnection(ctx, func(u SourceUpdate) bool {
+			c.setState(u.Err)
+			return true
+		})
+		return
+	}
+	if err := c.load(ctx); err != nil {
 		c.logger.Log("msg", "dynconf failed to load settings", "path", c.path, "err", err)
+		c.setState(err)
+	}
+
+	for u := range c.source.Watch(ctx) {
+		if u.Err != nil {
+			c.logger.Log("msg", "dynconf watch error", "path", c.path, "err", u.Err)
+		}
+		c.setState(u.Err)
+
+		c.update(u)
 	}
+}
+
+// update applies the source events to the settings and notifies the listeners about the changes.
+func (c *Config) update(u SourceUpdate) {
+	if c.onRawEvent != nil && len(u.raw) != 0 {
+		c.protect("onRawEvent", func() { c.onRawEvent(u.raw) })
+	}
+
+	// values are the events' values with the environment variables expanded.
+	values := make([]string, len(u.Events))
+	for i, e := range u.Events {
+		if !e.Deleted {
+			values[i] = c.expand(e.Setting, e.Value)
+		}
+	}
+	// The whole update is rejected if the resulting settings don't pass the validator.
+	if !c.validUpdate(u.Events, values) {
+		c.setRevision(u.Revision)
+		return
+	}
+
+	if c.lru != nil {
+		c.lazyMu.Lock()
+	}
+	now := c.now()
+	changes := make([]Change, 0, len(u.Events))
+	// revs are the revisions of the changes.
+	revs := make([]int64, 0, len(u.Events))
+	// existed indicates whether the changes' settings existed before them.
+	existed := make([]bool, 0, len(u.Events))
+	for i, e := range u.Events {
+		setting := e.Setting
+		v, ok := c.settings.Load(setting)
+		if !ok && c.lru != nil && (e.Deleted || !c.lru.isAbsent(setting)) {
+			// Only the cached settings are kept up to date.
+			continue
+		}
+		if c.lru != nil && e.Revision != 0 && e.Revision <= c.lru.revision(setting) {
+			// The setting was fetched after the change, e.g., the change of an evicted setting's watch.
+			continue
+		}
+		ch := Change{Setting: setting}
+		if ok {
+			ch.Old, _ = v.(string)
+		}
 
-	prefixLen := len(c.path)
-	// As long as the context has not been canceled,
-	// watch will retry on recoverable errors forever until reconnected.
-	updates := c.etcd.Watch(context.Background(), c.path, clientv3.WithPrefix())
-	for u := range updates {
-		if err := u.Err(); err != nil {
-			c.logger.Log("msg", "dynconf watch error", "path", c.path, "err", err)
+		if e.Deleted {
+			ch.Deleted = true
+			c.settings.Delete(setting)
+			if c.lru != nil {
+				c.lru.setAbsent(setting, true)
+			}
+		} else {
+			ch.New = values[i]
+			if !c.valid(setting, ch.New) {
+				continue
+			}
+			c.settings.Store(setting, ch.New)
+			if c.lru != nil {
+				c.lru.setAbsent(setting, false)
+			}
 		}
+		c.changedAt.Store(setting, now)
+		if c.lru != nil && e.Revision != 0 {
+			c.lru.setRevision(setting, e.Revision)
+		}
+		changes = append(changes, ch)
+		existed = append(existed, ok)
+		if e.Revision != 0 {
+			revs = append(revs, e.Revision)
+		} else {
+			revs = append(revs, u.Revision)
+		}
+	}
+	c.setRevision(u.Revision)
+	if c.lru != nil {
+		c.lazyMu.Unlock()
+	}
+	// The sources might report that nothing has changed, e.g., when they're polled.
+	if len(changes) == 0 {
+		return
+	}
+	c.notify()
+	c.dispatch(changes)
+	c.emit(changes, revs)
 
-		for _, e := range u.Events {
-			setting := string(e.Kv.Key)
-			setting = setting[prefixLen:]
+	if c.debounce > 0 {
+		c.postpone(changes, existed)
+		return
+	}
+	c.runCallbacks(changes)
+}
 
-			switch e.Type {
-			case clientv3.EventTypePut:
-				c.settings.Store(setting, string(e.Kv.Value))
-			case clientv3.EventTypeDelete:
-				c.settings.Delete(setting)
+// callback calls the update callbacks with the changes.
+// The panics are recovered and logged, see protect.
+func (c *Config) callback(changes []Change) {
+	if c.onDelete != nil {
+		for _, ch := range changes {
+			if ch.Deleted {
+				c.protect("onDelete", func() { c.onDelete(ch.Setting) })
 			}
 		}
+	}
+	if c.onChanges != nil {
+		c.protect("onChanges", func() { c.onChanges(changes) })
+	}
+	if c.onUpdate != nil {
+		c.protect("onUpdate", func() { c.onUpdate(c.Settings()) })
+	}
+}
+
+// expand replaces the environment variables in the setting value if WithEnvExpansion is enabled.
+func (c *Config) expand(setting, value string) string {
+	if !c.expandEnv {
+		return value
+	}
+
+	return os.Expand(value, func(key string) string {
+		v, ok := os.LookupEnv(key)
+		if !ok {
+			c.logger.Log("msg", "dynconf unknown environment variable", "path", c.path, "setting", setting, "var", key)
+		}
+		return v
+	})
+}
+
+// setRevision records the etcd revision the settings are up to date with.
+func (c *Config) setRevision(rev int64) {
+	c.mu.Lock()
+	if rev > c.revision {
+		c.revision = rev
+		c.revisionAt = time.Now()
+	}
+	c.mu.Unlock()
+}
+
+// setState records whether etcd is reachable according to the result of the last etcd request
+// or the connection's state change.
+func (c *Config) setState(err error) {
+	c.mu.Lock()
+	if connected := err == nil; connected != c.connected {
+		c.connected = connected
+		close(c.stateChanged)
+		c.stateChanged = make(chan struct{})
+	}
+	if err != nil {
+		c.lastErr = err
+	}
+	c.mu.Unlock()
+}
+
+// notify wakes up everyone waiting for the settings to be updated.
+func (c *Config) notify() {
+	c.mu.Lock()
+	c.updatedAt = time.Now()
+	close(c.updated)
+	c.updated = make(chan struct{})
+	c.mu.Unlock()
+}
+
+// updates returns a channel that is closed on the next settings update.
+func (c *Config) updates() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.updated
+}
+
+// AwaitFunc blocks until pred returns true for the current settings or ctx expires.
+// The predicate is checked right away and then after every settings update.
+func (c *Config) AwaitFunc(ctx context.Context, pred func(settings map[string]string) bool) error {
+	for {
+		// The channel is obtained before checking the settings
+		// so an update in between isn't missed.
+		updated := c.updates()
+		if pred(c.Settings()) {
+			return nil
+		}
+
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return fmt.Errorf("dynconf condition not met: %w", ctx.Err())
+		}
+	}
+}
+
+// Lag reports how stale the settings are compared to etcd.
+// It fetches the most recently modified key under the path and
+// compares its revision to the latest revision applied to the settings.
+// When the settings are behind, Lag returns the time passed since the settings were last updated,
+// i.e., the upper bound of the propagation delay. Otherwise it returns zero.
+//
+// Note, deleted keys can't be seen this way, so a pending deletion isn't reported as lag.
+func (c *Config) Lag(ctx context.Context) (time.Duration, error) {
+	if c.etcd == nil {
+		return 0, errNoEtcd
+	}
+	opts := append([]clientv3.OpOption{clientv3.WithPrefix()}, clientv3.WithLastRev()...)
+	r, err := c.etcd.Get(ctx, c.path, opts...)
+	if err != nil {
+		return 0, fmt.Errorf("dynconf failed to get latest revision: %w", err)
+	}
+	if len(r.Kvs) == 0 {
+		return 0, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r.Kvs[0].ModRevision <= c.revision {
+		return 0, nil
+	}
 
-		if c.onUpdate != nil {
-			c.onUpdate(c.Settings())
+	return time.Since(c.revisionAt), nil
+}
+
+// lookup returns the raw value of the given setting.
+// The values overridden by command-line flags take precedence over the ones from etcd.
+func (c *Config) lookup(setting string) (interface{}, bool) {
+	c.countRead(setting)
+
+	if v, ok := c.overrides.Load(setting); ok {
+		if p, _ := v.(*string); p != nil {
+			return *p, true
 		}
 	}
+
+	if c.lru != nil {
+		return c.lookupLazy(setting)
+	}
+
+	return c.settings.Load(setting)
+}
+
+// Lookup returns the raw value of the given setting and whether it was found,
+// so the callers can parse the settings whose types aren't supported by the getters.
+func (c *Config) Lookup(setting string) (string, bool) {
+	v, ok := c.lookup(setting)
+	if !ok {
+		return "", false
+	}
+
+	s, ok := v.(string)
+	return s, ok
+}
+
+// countRead increments the read counter of the setting.
+func (c *Config) countRead(setting string) {
+	v, ok := c.reads.Load(setting)
+	if !ok {
+		v, _ = c.reads.LoadOrStore(setting, new(uint64))
+	}
+	atomic.AddUint64(v.(*uint64), 1)
+}
+
+// AccessStats returns how many times each setting was read by getters
+// since the Config was created or the stats were reset.
+func (c *Config) AccessStats() map[string]uint64 {
+	stats := make(map[string]uint64)
+	c.reads.Range(func(key interface{}, value interface{}) bool {
+		k, _ := key.(string)
+		stats[k] = atomic.LoadUint64(value.(*uint64))
+		return true
+	})
+
+	return stats
+}
+
+// ResetAccessStats clears the settings' read counters.
+func (c *Config) ResetAccessStats() {
+	c.reads.Range(func(key interface{}, value interface{}) bool {
+		c.reads.Delete(key)
+		return true
+	})
 }
 
 // Settings returns all the settings.
@@ -176,238 +968,1591 @@ func (c *Config) Settings() map[string]string {
 	return ss
 }
 
-// String returns the string value of the given setting,
-// or defaultValue if it wasn't found.
-func (c *Config) String(setting, defaultValue string) string {
-	v, ok := c.settings.Load(setting)
-	if !ok {
-		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
-		return defaultValue
+// Has reports whether the given setting was found.
+func (c *Config) Has(setting string) bool {
+	return c.present(setting)
+}
+
+// Keys returns the sorted names of all the settings including the ones overridden by command-line flags,
+// so every returned setting is reported by Has.
+// With WithMaxKeys only the settings currently cached are returned.
+func (c *Config) Keys() []string {
+	seen := make(map[string]bool)
+	c.settings.Range(func(key interface{}, value interface{}) bool {
+		k, _ := key.(string)
+		seen[k] = true
+		return true
+	})
+	c.overrides.Range(func(key interface{}, value interface{}) bool {
+		if p, _ := value.(*string); p != nil {
+			k, _ := key.(string)
+			seen[k] = true
+		}
+		return true
+	})
+	if len(seen) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Len returns the number of the settings returned by Keys.
+func (c *Config) Len() int {
+	return len(c.Keys())
+}
+
+// Grouped returns all the settings grouped by the first segment of their names split by sep,
+// e.g., camera.fps and camera.res settings are put into the "camera" group as fps and res.
+// The settings whose names don't contain sep are put into the "" group.
+func (c *Config) Grouped(sep string) map[string]map[string]string {
+	ss := c.Settings()
+	if ss == nil {
+		return nil
+	}
+
+	groups := make(map[string]map[string]string)
+	for k, v := range ss {
+		group, name := "", k
+		if i := strings.Index(k, sep); i >= 0 {
+			group, name = k[:i], k[i+len(sep):]
+		}
+
+		if groups[group] == nil {
+			groups[group] = make(map[string]string)
+		}
+		groups[group][name] = v
+	}
+
+	return groups
+}
+
+// String returns the string value of the given setting,
+// or defaultValue if it wasn't found.
+func (c *Config) String(setting, defaultValue string) string {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	return s
+}
+
+// TextBlock returns the multi-line text value of the given setting, e.g., PEM,
+// with the line endings normalized to LF and the trailing blank lines trimmed,
+// or the empty string if it wasn't found.
+func (c *Config) TextBlock(setting string) string {
+	s := c.String(setting, "")
+	if s == "" {
+		return s
+	}
+
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+
+	trimmed := strings.TrimRight(s, " \t\n")
+	if len(trimmed) < len(s) && strings.Contains(s[len(trimmed):], "\n") {
+		// The last line keeps its line ending.
+		trimmed += "\n"
+	}
+
+	return trimmed
+}
+
+// StringRequired returns the string value of the given setting,
+// or error if it wasn't found.
+func (c *Config) StringRequired(setting string) (string, error) {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return "", fmt.Errorf("dynconf setting not found: %s", setting)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return "", fmt.Errorf("dynconf invalid string value: %s", setting)
+	}
+
+	return s, nil
+}
+
+// Enum returns the string value of the given setting if it's one of the allowed values,
+// or defaultValue if it wasn't found or it isn't allowed.
+func (c *Config) Enum(setting string, allowed []string, defaultValue string) string {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	for _, a := range allowed {
+		if s == a {
+			return s
+		}
+	}
+	c.logger.Log("msg", "dynconf invalid enum setting", "path", c.path, "setting", setting, "value", s, "allowed", strings.Join(allowed, "|"))
+
+	return defaultValue
+}
+
+// Bytes returns the base64-decoded value of the given setting,
+// or defaultValue if it wasn't found or decoding failed.
+// The value must be encoded with the standard padded alphabet, see RFC 4648.
+func (c *Config) Bytes(setting string, defaultValue []byte) []byte {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid base64 setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return b
+}
+
+// HexBytes returns the hex-decoded value of the given setting,
+// or defaultValue if it wasn't found or decoding failed.
+func (c *Config) HexBytes(setting string, defaultValue []byte) []byte {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid hex setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return b
+}
+
+// UUID returns the UUID value of the given setting,
+// or defaultValue if it wasn't found or it isn't a valid RFC 4122 UUID.
+func (c *Config) UUID(setting string, defaultValue uuid.UUID) uuid.UUID {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	u, err := parseUUID(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid UUID setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return u
+}
+
+// UUIDRequired returns the UUID value of the given setting,
+// or error if it wasn't found or it isn't a valid RFC 4122 UUID.
+func (c *Config) UUIDRequired(setting string) (uuid.UUID, error) {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return uuid.Nil, fmt.Errorf("dynconf setting not found: %s", setting)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return uuid.Nil, fmt.Errorf("dynconf invalid string value: %s", setting)
+	}
+
+	u, err := parseUUID(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid UUID setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return uuid.Nil, fmt.Errorf("dynconf invalid UUID setting: %s", setting)
+	}
+
+	return u, nil
+}
+
+// SemVer returns the semantic version of the given setting, e.g., "1.4.2" or "v2.0.0-rc.1",
+// or defaultValue if it wasn't found or parsing failed.
+// The versions can be compared with Compare, Equal, and LessThan methods.
+func (c *Config) SemVer(setting string, defaultValue semver.Version) semver.Version {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	ver, err := semver.NewVersion(strings.TrimPrefix(s, "v"))
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid semantic version setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return *ver
+}
+
+// parseUUID parses the canonical form of a UUID, e.g., f47ac10b-58cc-4372-a567-0e02b2c3d479,
+// and makes sure its variant is RFC 4122.
+func parseUUID(s string) (uuid.UUID, error) {
+	if len(s) != 36 {
+		return uuid.Nil, fmt.Errorf("invalid UUID length: %d", len(s))
+	}
+	u, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if u.Variant() != uuid.RFC4122 {
+		return uuid.Nil, fmt.Errorf("invalid UUID variant: %s", u.Variant())
+	}
+
+	return u, nil
+}
+
+// Boolean returns the boolean value of the given setting,
+// or defaultValue if it wasn't found or parsing failed.
+func (c *Config) Boolean(setting string, defaultValue bool) bool {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid boolean setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return b
+}
+
+// BooleanRequired returns the boolean value of the given setting,
+// or error if it wasn't found or parsing failed.
+func (c *Config) BooleanRequired(setting string) (bool, error) {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return false, fmt.Errorf("dynconf setting not found: %s", setting)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return false, fmt.Errorf("dynconf invalid string value: %s", setting)
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid boolean setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return false, fmt.Errorf("dynconf invalid boolean setting: %s", setting)
+	}
+
+	return b, nil
+}
+
+// Integer returns the integer value of the given setting,
+// or defaultValue if it wasn't found or parsing failed.
+func (c *Config) Integer(setting string, defaultValue int) int {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid integer setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return i
+}
+
+// IntegerRequired returns the integer value of the given setting,
+// or error if it wasn't found or parsing failed.
+func (c *Config) IntegerRequired(setting string) (int, error) {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return 0, fmt.Errorf("dynconf setting not found: %s", setting)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return 0, fmt.Errorf("dynconf invalid string value: %s", setting)
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid integer setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return 0, fmt.Errorf("dynconf invalid integer setting: %s", setting)
+	}
+
+	return i, nil
+}
+
+// IntegerInRange returns the integer value of the given setting limited to [min, max] range,
+// or defaultValue if it wasn't found or parsing failed.
+// The out of range values are clamped, or replaced by defaultValue if WithRejectOutOfRange is set.
+func (c *Config) IntegerInRange(setting string, min, max, defaultValue int) int {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid integer setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	if i >= min && i <= max {
+		return i
+	}
+	c.logger.Log("msg", "dynconf integer setting out of range", "path", c.path, "setting", setting, "value", s, "min", min, "max", max)
+	switch {
+	case c.rejectOutOfRange:
+		return defaultValue
+	case i < min:
+		return min
+	default:
+		return max
+	}
+}
+
+// Int64 returns the int64 value of the given setting,
+// or defaultValue if it wasn't found or parsing failed.
+func (c *Config) Int64(setting string, defaultValue int64) int64 {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid integer setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return i
+}
+
+// Int64Required returns the int64 value of the given setting,
+// or error if it wasn't found or parsing failed.
+func (c *Config) Int64Required(setting string) (int64, error) {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return 0, fmt.Errorf("dynconf setting not found: %s", setting)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return 0, fmt.Errorf("dynconf invalid string value: %s", setting)
+	}
+
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid integer setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return 0, fmt.Errorf("dynconf invalid integer setting: %s", setting)
+	}
+
+	return i, nil
+}
+
+// Uint returns the uint value of the given setting,
+// or defaultValue if it wasn't found or parsing failed, e.g., the value is negative.
+func (c *Config) Uint(setting string, defaultValue uint) uint {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	u, err := strconv.ParseUint(s, 10, 0)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid unsigned integer setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return uint(u)
+}
+
+// UintRequired returns the uint value of the given setting,
+// or error if it wasn't found or parsing failed.
+func (c *Config) UintRequired(setting string) (uint, error) {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return 0, fmt.Errorf("dynconf setting not found: %s", setting)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return 0, fmt.Errorf("dynconf invalid string value: %s", setting)
+	}
+
+	u, err := strconv.ParseUint(s, 10, 0)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid unsigned integer setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return 0, fmt.Errorf("dynconf invalid unsigned integer setting: %s", setting)
+	}
+
+	return uint(u), nil
+}
+
+// Uint64 returns the uint64 value of the given setting,
+// or defaultValue if it wasn't found or parsing failed, e.g., the value is negative.
+func (c *Config) Uint64(setting string, defaultValue uint64) uint64 {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	u, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid unsigned integer setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return u
+}
+
+// Uint64Required returns the uint64 value of the given setting,
+// or error if it wasn't found or parsing failed.
+func (c *Config) Uint64Required(setting string) (uint64, error) {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return 0, fmt.Errorf("dynconf setting not found: %s", setting)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return 0, fmt.Errorf("dynconf invalid string value: %s", setting)
+	}
+
+	u, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid unsigned integer setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return 0, fmt.Errorf("dynconf invalid unsigned integer setting: %s", setting)
+	}
+
+	return u, nil
+}
+
+// Int32 returns the int32 value of the given setting,
+// or defaultValue if it wasn't found or parsing failed, e.g., the value overflows int32.
+func (c *Config) Int32(setting string, defaultValue int32) int32 {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	i, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid integer setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return int32(i)
+}
+
+// Uint32 returns the uint32 value of the given setting,
+// or defaultValue if it wasn't found or parsing failed, e.g., the value overflows uint32.
+func (c *Config) Uint32(setting string, defaultValue uint32) uint32 {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	u, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid unsigned integer setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return uint32(u)
+}
+
+// Float returns the float value of the given setting,
+// or defaultValue if it wasn't found or parsing failed.
+func (c *Config) Float(setting string, defaultValue float64) float64 {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid float setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return f
+}
+
+// FloatInRange returns the float value of the given setting limited to [min, max] range,
+// or defaultValue if it wasn't found or parsing failed.
+// The out of range values are clamped, or replaced by defaultValue if WithRejectOutOfRange is set.
+func (c *Config) FloatInRange(setting string, min, max, defaultValue float64) float64 {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) {
+		c.logger.Log("msg", "dynconf invalid float setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	if f >= min && f <= max {
+		return f
+	}
+	c.logger.Log("msg", "dynconf float setting out of range", "path", c.path, "setting", setting, "value", s, "min", min, "max", max)
+	switch {
+	case c.rejectOutOfRange:
+		return defaultValue
+	case f < min:
+		return min
+	default:
+		return max
+	}
+}
+
+// FloatRequired returns the float value of the given setting,
+// or error if it wasn't found or parsing failed.
+func (c *Config) FloatRequired(setting string) (float64, error) {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return 0, fmt.Errorf("dynconf setting not found: %s", setting)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return 0, fmt.Errorf("dynconf invalid string value: %s", setting)
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid float setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return 0, fmt.Errorf("dynconf invalid float setting: %s", setting)
+	}
+
+	return f, nil
+}
+
+// Float32 returns the float32 value of the given setting,
+// or defaultValue if it wasn't found or parsing failed, e.g., the value is out of float32 range.
+func (c *Config) Float32(setting string, defaultValue float32) float32 {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	f, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid float setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return float32(f)
+}
+
+// Float32Required returns the float32 value of the given setting,
+// or error if it wasn't found or parsing failed.
+func (c *Config) Float32Required(setting string) (float32, error) {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return 0, fmt.Errorf("dynconf setting not found: %s", setting)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return 0, fmt.Errorf("dynconf invalid string value: %s", setting)
+	}
+
+	f, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid float setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return 0, fmt.Errorf("dynconf invalid float setting: %s", setting)
+	}
+
+	return float32(f), nil
+}
+
+// Percent returns the percentage value of the given setting as a fraction in [0, 1] range,
+// or defaultValue if it wasn't found or parsing failed.
+// The value can be written as "15%", "15", or "0.15", i.e., values without the percent sign
+// are treated as percentages if they're greater than 1. The out of range values are clamped.
+func (c *Config) Percent(setting string, defaultValue float64) float64 {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	num := strings.TrimSpace(s)
+	percentSign := strings.HasSuffix(num, "%")
+	if percentSign {
+		num = strings.TrimSpace(strings.TrimSuffix(num, "%"))
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || math.IsNaN(f) {
+		c.logger.Log("msg", "dynconf invalid percent setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+	if percentSign || f > 1 {
+		f /= 100
+	}
+
+	switch {
+	case f < 0:
+		c.logger.Log("msg", "dynconf percent setting out of range", "path", c.path, "setting", setting, "value", s)
+		return 0
+	case f > 1:
+		c.logger.Log("msg", "dynconf percent setting out of range", "path", c.path, "setting", setting, "value", s)
+		return 1
+	}
+
+	return f
+}
+
+// Decimal returns the arbitrary-precision decimal value of the given setting, e.g., "19.99",
+// or defaultValue if it wasn't found or parsing failed.
+// Unlike Float, the value is exact, so it's suitable for money.
+func (c *Config) Decimal(setting string, defaultValue decimal.Decimal) decimal.Decimal {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid decimal setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return d
+}
+
+// BigInt returns the arbitrary-precision integer value of the given setting,
+// or defaultValue if it wasn't found or parsing failed.
+func (c *Config) BigInt(setting string, defaultValue *big.Int) *big.Int {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid integer setting", "path", c.path, "setting", setting, "value", s, "err", "invalid big integer")
+		return defaultValue
+	}
+
+	return i
+}
+
+// BigIntRequired returns the arbitrary-precision integer value of the given setting,
+// or error if it wasn't found or parsing failed.
+func (c *Config) BigIntRequired(setting string) (*big.Int, error) {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return nil, fmt.Errorf("dynconf setting not found: %s", setting)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return nil, fmt.Errorf("dynconf invalid string value: %s", setting)
+	}
+
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid integer setting", "path", c.path, "setting", setting, "value", s, "err", "invalid big integer")
+		return nil, fmt.Errorf("dynconf invalid integer setting: %s", setting)
+	}
+
+	return i, nil
+}
+
+// FileMode returns the permission bits of the given setting written in octal, e.g., "0640",
+// or defaultValue if it wasn't found or parsing failed.
+func (c *Config) FileMode(setting string, defaultValue os.FileMode) os.FileMode {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	m, err := strconv.ParseUint(strings.TrimPrefix(s, "0o"), 8, 32)
+	if err == nil && m > uint64(os.ModePerm) {
+		err = errors.New("only permission bits are allowed")
+	}
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid file mode setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return os.FileMode(m)
+}
+
+// Date returns the date value of the given setting,
+// or defaultValue if it wasn't found or RFC3339 parsing failed.
+func (c *Config) Date(setting string, format string, defaultValue time.Time) time.Time {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	t, err := time.Parse(format, s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid RFC3339 date setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return t
+}
+
+// DateRequired returns the date value of the given setting,
+// or error if it wasn't found or RFC3339 parsing failed.
+func (c *Config) DateRequired(setting string, format string) (time.Time, error) {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return time.Time{}, fmt.Errorf("dynconf setting not found: %s", setting)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return time.Time{}, fmt.Errorf("dynconf invalid string value: %s", setting)
+	}
+
+	t, err := time.Parse(format, s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid RFC3339 date setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return time.Time{}, fmt.Errorf("dynconf invalid RFC3339 date setting: %s", setting)
+	}
+
+	return t, nil
+}
+
+// Struct returns the struct value of the given setting decoded from JSON,
+// or MessagePack or YAML if WithStructFormatDetection is set and the value isn't valid JSON.
+// If out implements json.Unmarshaler, the value is passed to its UnmarshalJSON as is.
+func (c *Config) Struct(setting string, out interface{}) error {
+	return c.StructWith(setting, out, c.decodeStruct)
+}
+
+// StructYAML returns the struct value of the given setting decoded from YAML.
+// Note, the fields are matched by their yaml tags, or lowercased names if they have no tags.
+func (c *Config) StructYAML(setting string, out interface{}) error {
+	return c.StructWith(setting, out, yaml.Unmarshal)
+}
+
+// StructMsgpack returns the struct value of the given setting decoded from MessagePack.
+// Note, the fields are matched by their msgpack tags, or names if they have no tags.
+func (c *Config) StructMsgpack(setting string, out interface{}) error {
+	return c.StructWith(setting, out, msgpack.Unmarshal)
+}
+
+// StructTOML returns the struct value of the given setting decoded from TOML.
+// Note, the fields are matched by their toml tags, or names if they have no tags.
+func (c *Config) StructTOML(setting string, out interface{}) error {
+	return c.StructWith(setting, out, toml.Unmarshal)
+}
+
+// StructAs returns the struct value of the given setting decoded with the decoder of the format,
+// see WithDecoder, or error if the format has no decoder.
+func (c *Config) StructAs(setting, format string, out interface{}) error {
+	decode, ok := c.decoders[format]
+	if !ok {
+		c.logger.Log("msg", "dynconf unknown decoder", "path", c.path, "setting", setting, "format", format)
+		return fmt.Errorf("dynconf unknown decoder: %s", format)
+	}
+
+	return c.StructWith(setting, out, decode)
+}
+
+// StructWith returns the struct value of the given setting decoded with the decode function,
+// so any codec such as msgpack or gob can be used.
+func (c *Config) StructWith(setting string, out interface{}, decode func(data []byte, out interface{}) error) error {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return errors.New("setting not found")
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return errors.New("invalid string value")
+	}
+
+	return decode([]byte(s), out)
+}
+
+// StructOrDefault decodes the JSON value of the given setting into out,
+// or copies def into out if it wasn't found or decoding failed, so out is never partially decoded.
+// The def must be a value or a pointer of out's type, e.g.,
+//
+//	var limits Limits
+//	c.StructOrDefault("limits", &limits, Limits{RPS: 100})
+//
+// It panics if out isn't a non-nil pointer or def has a different type.
+func (c *Config) StructOrDefault(setting string, out, def interface{}) {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		panic(fmt.Sprintf("dynconf StructOrDefault requires a non-nil pointer, got %T", out))
+	}
+	dv := reflect.ValueOf(def)
+	if dv.Kind() == reflect.Ptr && dv.Type() == rv.Type() {
+		dv = dv.Elem()
+	}
+	if !dv.IsValid() || dv.Type() != rv.Elem().Type() {
+		panic(fmt.Sprintf("dynconf StructOrDefault default %T doesn't match %T", def, out))
+	}
+
+	decoded := reflect.New(rv.Elem().Type())
+	if err := c.Struct(setting, decoded.Interface()); err != nil {
+		c.logger.Log("msg", "dynconf invalid struct setting", "path", c.path, "setting", setting, "err", c.maskedErr(err))
+		rv.Elem().Set(dv)
+		return
+	}
+	rv.Elem().Set(decoded.Elem())
+}
+
+// decodeStruct decodes JSON data into out, or MessagePack or YAML data if the format detection is enabled,
+// with the registered decoders.
+func (c *Config) decodeStruct(data []byte, out interface{}) error {
+	if _, ok := out.(json.Unmarshaler); ok || !c.detectStructFormat || json.Valid(data) {
+		return c.decoders["json"](data, out)
+	}
+	// MessagePack maps and arrays start with a byte which can't start UTF-8 text.
+	if !utf8.Valid(data) {
+		return c.decoders["msgpack"](data, out)
+	}
+
+	return c.decoders["yaml"](data, out)
+}
+
+// unmarshalJSON decodes JSON data into out.
+// Unlike json.Unmarshal, it doesn't validate the data if out implements json.Unmarshaler.
+func unmarshalJSON(data []byte, out interface{}) error {
+	if unmarshaler, ok := out.(json.Unmarshaler); ok && unmarshaler != nil {
+		return unmarshaler.UnmarshalJSON(data)
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+// JSONMap returns the JSON object value of the given setting decoded into a map,
+// or error if it wasn't found or the value isn't a JSON object.
+func (c *Config) JSONMap(setting string) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	if err := c.StructWith(setting, &m, json.Unmarshal); err != nil {
+		return nil, err
+	}
+	if m == nil {
+		c.logger.Log("msg", "dynconf invalid JSON object setting", "path", c.path, "setting", setting, "err", "null")
+		return nil, fmt.Errorf("dynconf invalid JSON object setting: %s", setting)
+	}
+
+	return m, nil
+}
+
+// Duration returns the duration value of the given setting,
+// or defaultValue if it wasn't found or parsing failed.
+func (c *Config) Duration(setting string, defaultValue time.Duration) time.Duration {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid duration setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return d
+}
+
+// DurationRequired returns the duration value of the given setting,
+// or error if it wasn't found or parsing failed.
+func (c *Config) DurationRequired(setting string) (time.Duration, error) {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return 0, fmt.Errorf("dynconf setting not found: %s", setting)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return 0, fmt.Errorf("dynconf invalid string value: %s", setting)
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid duration setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return 0, fmt.Errorf("dynconf invalid duration setting: %s", setting)
+	}
+
+	return d, nil
+}
+
+// Cron returns the cron expression of the given setting,
+// or defaultValue if it wasn't found or the expression is invalid.
+// By default only standard 5-field expressions are accepted, see WithCronSeconds.
+func (c *Config) Cron(setting string, defaultValue string) string {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	if _, err := c.cronParser.Parse(s); err != nil {
+		c.logger.Log("msg", "dynconf invalid cron setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return s
+}
+
+// CronRequired returns the cron expression of the given setting,
+// or error if it wasn't found or the expression is invalid.
+func (c *Config) CronRequired(setting string) (string, error) {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return "", fmt.Errorf("dynconf setting not found: %s", setting)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return "", fmt.Errorf("dynconf invalid string value: %s", setting)
+	}
+
+	if _, err := c.cronParser.Parse(s); err != nil {
+		c.logger.Log("msg", "dynconf invalid cron setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return "", fmt.Errorf("dynconf invalid cron setting: %s", setting)
+	}
+
+	return s, nil
+}
+
+// StringArray returns the string array value of the given setting,
+func (c *Config) StringArray(setting string, delimiter string) []string {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return nil
+	}
+
+	ss := strings.Split(s, delimiter)
+	if c.sortArrays {
+		sort.Strings(ss)
+	}
+
+	return ss
+}
+
+// StringArrayRequired returns the string array value of the given setting,
+// or error if it wasn't found.
+func (c *Config) StringArrayRequired(setting string, delimiter string) ([]string, error) {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return nil, fmt.Errorf("dynconf setting not found: %s", setting)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return nil, fmt.Errorf("dynconf invalid string value: %s", setting)
+	}
+
+	ss := strings.Split(s, delimiter)
+	if c.sortArrays {
+		sort.Strings(ss)
+	}
+
+	return ss, nil
+}
+
+// StringSet returns the distinct elements of the given setting split by delimiter,
+// where the elements are trimmed of whitespace and the empty ones are skipped.
+// It returns nil if the setting wasn't found.
+func (c *Config) StringSet(setting string, delimiter string) map[string]struct{} {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return nil
+	}
+
+	set := make(map[string]struct{})
+	for _, e := range strings.Split(s, delimiter) {
+		if e = strings.TrimSpace(e); e != "" {
+			set[e] = struct{}{}
+		}
+	}
+
+	return set
+}
+
+// IntegerArray returns the integer array value of the given setting,
+func (c *Config) IntegerArray(setting string, delimiter string) []int {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return nil
+	}
+
+	ss := strings.Split(s, delimiter)
+	is := make([]int, len(ss))
+	for i, s := range ss {
+		is[i], _ = strconv.Atoi(s)
+	}
+	if c.sortArrays {
+		sort.Ints(is)
+	}
+
+	return is
+}
+
+// IntegerArrayRequired returns the integer array value of the given setting,
+// or error if it wasn't found or any of the elements is invalid.
+func (c *Config) IntegerArrayRequired(setting string, delimiter string) ([]int, error) {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return nil, fmt.Errorf("dynconf setting not found: %s", setting)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return nil, fmt.Errorf("dynconf invalid string value: %s", setting)
+	}
+
+	ss := strings.Split(s, delimiter)
+	is := make([]int, len(ss))
+	for i, e := range ss {
+		x, err := strconv.Atoi(e)
+		if err != nil {
+			c.logger.Log("msg", "dynconf invalid integer element", "path", c.path, "setting", setting, "value", e, "err", err)
+			return nil, fmt.Errorf("dynconf invalid integer setting %s at index %d: %q", setting, i, e)
+		}
+		is[i] = x
+	}
+	if c.sortArrays {
+		sort.Ints(is)
+	}
+
+	return is, nil
+}
+
+// Int64Array returns the int64 array value of the given setting,
+// where invalid elements are zero.
+func (c *Config) Int64Array(setting string, delimiter string) []int64 {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return nil
+	}
+
+	ss := strings.Split(s, delimiter)
+	is := make([]int64, len(ss))
+	for i, s := range ss {
+		is[i], _ = strconv.ParseInt(s, 10, 64)
+	}
+	if c.sortArrays {
+		sort.Slice(is, func(i, j int) bool { return is[i] < is[j] })
+	}
+
+	return is
+}
+
+// UintArray returns the uint array value of the given setting,
+// where invalid elements, e.g., negative numbers, are zero.
+func (c *Config) UintArray(setting string, delimiter string) []uint {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return nil
 	}
 
 	s, ok := v.(string)
 	if !ok {
 		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
-		return defaultValue
+		return nil
 	}
 
-	return s
+	ss := strings.Split(s, delimiter)
+	us := make([]uint, len(ss))
+	for i, s := range ss {
+		u, _ := strconv.ParseUint(s, 10, 0)
+		us[i] = uint(u)
+	}
+	if c.sortArrays {
+		sort.Slice(us, func(i, j int) bool { return us[i] < us[j] })
+	}
+
+	return us
 }
 
-// StringRequired returns the string value of the given setting,
-// or error if it wasn't found.
-func (c *Config) StringRequired(setting string) (string, error) {
-	v, ok := c.settings.Load(setting)
+// FloatArray returns the float array value of the given setting,
+func (c *Config) FloatArray(setting string, delimiter string) []float64 {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
-		return "", fmt.Errorf("dynconf setting not found: %s", setting)
+		return nil
 	}
 
 	s, ok := v.(string)
 	if !ok {
 		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
-		return "", fmt.Errorf("dynconf invalid string value: %s", setting)
+		return nil
 	}
 
-	return s, nil
+	ss := strings.Split(s, delimiter)
+	fs := make([]float64, len(ss))
+	for i, s := range ss {
+		fs[i], _ = strconv.ParseFloat(s, 64)
+	}
+	if c.sortArrays {
+		sort.Float64s(fs)
+	}
+
+	return fs
 }
 
-// Boolean returns the boolean value of the given setting,
-// or defaultValue if it wasn't found or parsing failed.
-func (c *Config) Boolean(setting string, defaultValue bool) bool {
-	v, ok := c.settings.Load(setting)
+// FloatArrayRequired returns the float array value of the given setting,
+// or error if it wasn't found or any of the elements is invalid.
+func (c *Config) FloatArrayRequired(setting string, delimiter string) ([]float64, error) {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
-		return defaultValue
+		return nil, fmt.Errorf("dynconf setting not found: %s", setting)
 	}
 
 	s, ok := v.(string)
 	if !ok {
 		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
-		return defaultValue
+		return nil, fmt.Errorf("dynconf invalid string value: %s", setting)
 	}
 
-	b, err := strconv.ParseBool(s)
-	if err != nil {
-		c.logger.Log("msg", "dynconf invalid boolean setting", "path", c.path, "setting", setting, "value", s, "err", err)
-		return defaultValue
+	ss := strings.Split(s, delimiter)
+	fs := make([]float64, len(ss))
+	for i, e := range ss {
+		x, err := strconv.ParseFloat(e, 64)
+		if err != nil {
+			c.logger.Log("msg", "dynconf invalid float element", "path", c.path, "setting", setting, "value", e, "err", err)
+			return nil, fmt.Errorf("dynconf invalid float setting %s at index %d: %q", setting, i, e)
+		}
+		fs[i] = x
+	}
+	if c.sortArrays {
+		sort.Float64s(fs)
 	}
 
-	return b
+	return fs, nil
 }
 
-// BooleanRequired returns the boolean value of the given setting,
-// or error if it wasn't found or parsing failed.
-func (c *Config) BooleanRequired(setting string) (bool, error) {
-	v, ok := c.settings.Load(setting)
+// DateArray returns the date array value of the given setting,
+func (c *Config) DateArray(setting string, format string, delimiter string) []time.Time {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
-		return false, fmt.Errorf("dynconf setting not found: %s", setting)
+		return nil
 	}
 
 	s, ok := v.(string)
 	if !ok {
 		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
-		return false, fmt.Errorf("dynconf invalid string value: %s", setting)
+		return nil
 	}
 
-	b, err := strconv.ParseBool(s)
-	if err != nil {
-		c.logger.Log("msg", "dynconf invalid boolean setting", "path", c.path, "setting", setting, "value", s, "err", err)
-		return false, fmt.Errorf("dynconf invalid boolean setting: %s", setting)
+	ss := strings.Split(s, delimiter)
+	ts := make([]time.Time, len(ss))
+	for i, s := range ss {
+		ts[i], _ = time.Parse(format, s)
 	}
 
-	return b, nil
+	return ts
 }
 
-// Integer returns the integer value of the given setting,
-// or defaultValue if it wasn't found or parsing failed.
-func (c *Config) Integer(setting string, defaultValue int) int {
-	v, ok := c.settings.Load(setting)
+// DateArrayRequired returns the date array value of the given setting,
+// or error if it wasn't found or any of the elements is invalid.
+func (c *Config) DateArrayRequired(setting string, format string, delimiter string) ([]time.Time, error) {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
-		return defaultValue
+		return nil, fmt.Errorf("dynconf setting not found: %s", setting)
 	}
 
 	s, ok := v.(string)
 	if !ok {
 		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
-		return defaultValue
+		return nil, fmt.Errorf("dynconf invalid string value: %s", setting)
 	}
 
-	i, err := strconv.Atoi(s)
-	if err != nil {
-		c.logger.Log("msg", "dynconf invalid integer setting", "path", c.path, "setting", setting, "value", s, "err", err)
-		return defaultValue
+	ss := strings.Split(s, delimiter)
+	ts := make([]time.Time, len(ss))
+	for i, e := range ss {
+		x, err := time.Parse(format, e)
+		if err != nil {
+			c.logger.Log("msg", "dynconf invalid date element", "path", c.path, "setting", setting, "value", e, "err", err)
+			return nil, fmt.Errorf("dynconf invalid date setting %s at index %d: %q", setting, i, e)
+		}
+		ts[i] = x
 	}
 
-	return i
+	return ts, nil
 }
 
-// IntegerRequired returns the integer value of the given setting,
-// or error if it wasn't found or parsing failed.
-func (c *Config) IntegerRequired(setting string) (int, error) {
-	v, ok := c.settings.Load(setting)
+// DurationArray returns the duration array value of the given setting, e.g., "1s,5s,30s".
+// Invalid elements are zero.
+func (c *Config) DurationArray(setting string, delimiter string) []time.Duration {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
-		return 0, fmt.Errorf("dynconf setting not found: %s", setting)
+		return nil
 	}
 
 	s, ok := v.(string)
 	if !ok {
 		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
-		return 0, fmt.Errorf("dynconf invalid string value: %s", setting)
+		return nil
 	}
 
-	i, err := strconv.Atoi(s)
-	if err != nil {
-		c.logger.Log("msg", "dynconf invalid integer setting", "path", c.path, "setting", setting, "value", s, "err", err)
-		return 0, fmt.Errorf("dynconf invalid integer setting: %s", setting)
+	ss := strings.Split(s, delimiter)
+	ds := make([]time.Duration, len(ss))
+	for i, s := range ss {
+		ds[i], _ = time.ParseDuration(s)
 	}
 
-	return i, nil
+	return ds
 }
 
-// Int64 returns the int64 value of the given setting,
-// or defaultValue if it wasn't found or parsing failed.
-func (c *Config) Int64(setting string, defaultValue int64) int64 {
-	v, ok := c.settings.Load(setting)
+// BooleanArray returns the boolean array value of the given setting,
+func (c *Config) BooleanArray(setting string, delimiter string) []bool {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
-		return defaultValue
+		return nil
 	}
 
 	s, ok := v.(string)
 	if !ok {
 		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
-		return defaultValue
+		return nil
 	}
 
-	i, err := strconv.ParseInt(s, 10, 64)
-	if err != nil {
-		c.logger.Log("msg", "dynconf invalid integer setting", "path", c.path, "setting", setting, "value", s, "err", err)
-		return defaultValue
+	ss := strings.Split(s, delimiter)
+	bs := make([]bool, len(ss))
+	for i, s := range ss {
+		bs[i], _ = strconv.ParseBool(s)
 	}
 
-	return i
+	return bs
 }
 
-// Int64Required returns the int64 value of the given setting,
-// or error if it wasn't found or parsing failed.
-func (c *Config) Int64Required(setting string) (int64, error) {
-	v, ok := c.settings.Load(setting)
+// BooleanArrayRequired returns the boolean array value of the given setting,
+// or error if it wasn't found or any of the elements is invalid.
+func (c *Config) BooleanArrayRequired(setting string, delimiter string) ([]bool, error) {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
-		return 0, fmt.Errorf("dynconf setting not found: %s", setting)
+		return nil, fmt.Errorf("dynconf setting not found: %s", setting)
 	}
 
 	s, ok := v.(string)
 	if !ok {
 		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
-		return 0, fmt.Errorf("dynconf invalid string value: %s", setting)
+		return nil, fmt.Errorf("dynconf invalid string value: %s", setting)
 	}
 
-	i, err := strconv.ParseInt(s, 10, 64)
-	if err != nil {
-		c.logger.Log("msg", "dynconf invalid integer setting", "path", c.path, "setting", setting, "value", s, "err", err)
-		return 0, fmt.Errorf("dynconf invalid integer setting: %s", setting)
+	ss := strings.Split(s, delimiter)
+	bs := make([]bool, len(ss))
+	for i, e := range ss {
+		x, err := strconv.ParseBool(e)
+		if err != nil {
+			c.logger.Log("msg", "dynconf invalid boolean element", "path", c.path, "setting", setting, "value", e, "err", err)
+			return nil, fmt.Errorf("dynconf invalid boolean setting %s at index %d: %q", setting, i, e)
+		}
+		bs[i] = x
 	}
 
-	return i, nil
+	return bs, nil
 }
 
-// Float returns the float value of the given setting,
-// or defaultValue if it wasn't found or parsing failed.
-func (c *Config) Float(setting string, defaultValue float64) float64 {
-	v, ok := c.settings.Load(setting)
+// Endpoints returns the host:port endpoints of the given setting split by delimiter.
+// Invalid endpoints are skipped. It returns nil if the setting wasn't found or its value is empty.
+func (c *Config) Endpoints(setting string, delimiter string) []string {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
-		return defaultValue
+		return nil
 	}
 
 	s, ok := v.(string)
 	if !ok {
 		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
-		return defaultValue
+		return nil
+	}
+	if s == "" {
+		return nil
 	}
 
-	f, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		c.logger.Log("msg", "dynconf invalid float setting", "path", c.path, "setting", setting, "value", s, "err", err)
-		return defaultValue
+	var es []string
+	for _, e := range strings.Split(s, delimiter) {
+		if _, _, err := net.SplitHostPort(e); err != nil {
+			c.logger.Log("msg", "dynconf invalid endpoint", "path", c.path, "setting", setting, "value", e, "err", err)
+			continue
+		}
+		es = append(es, e)
 	}
 
-	return f
+	return es
 }
 
-// FloatRequired returns the float value of the given setting,
-// or error if it wasn't found or parsing failed.
-func (c *Config) FloatRequired(setting string) (float64, error) {
-	v, ok := c.settings.Load(setting)
+// EndpointsRequired returns the host:port endpoints of the given setting split by delimiter,
+// or error if it wasn't found, its value is empty, or any of the endpoints is invalid.
+func (c *Config) EndpointsRequired(setting string, delimiter string) ([]string, error) {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
-		return 0, fmt.Errorf("dynconf setting not found: %s", setting)
+		return nil, fmt.Errorf("dynconf setting not found: %s", setting)
 	}
 
 	s, ok := v.(string)
 	if !ok {
 		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
-		return 0, fmt.Errorf("dynconf invalid string value: %s", setting)
+		return nil, fmt.Errorf("dynconf invalid string value: %s", setting)
+	}
+	if s == "" {
+		return nil, fmt.Errorf("dynconf empty endpoints setting: %s", setting)
 	}
 
-	f, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		c.logger.Log("msg", "dynconf invalid float setting", "path", c.path, "setting", setting, "value", s, "err", err)
-		return 0, fmt.Errorf("dynconf invalid float setting: %s", setting)
+	es := strings.Split(s, delimiter)
+	for i, e := range es {
+		if _, _, err := net.SplitHostPort(e); err != nil {
+			c.logger.Log("msg", "dynconf invalid endpoint", "path", c.path, "setting", setting, "value", e, "err", err)
+			return nil, fmt.Errorf("dynconf invalid endpoint setting %s at index %d: %q", setting, i, e)
+		}
 	}
 
-	return f, nil
+	return es, nil
 }
 
-// Date returns the date value of the given setting,
-// or defaultValue if it wasn't found or RFC3339 parsing failed.
-func (c *Config) Date(setting string, format string, defaultValue time.Time) time.Time {
-	v, ok := c.settings.Load(setting)
+// HostPort returns the host:port endpoint of the given setting,
+// or defaultValue if it wasn't found or it isn't a valid endpoint.
+func (c *Config) HostPort(setting string, defaultValue string) string {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
 		return defaultValue
@@ -419,64 +2564,81 @@ func (c *Config) Date(setting string, format string, defaultValue time.Time) tim
 		return defaultValue
 	}
 
-	t, err := time.Parse(format, s)
-	if err != nil {
-		c.logger.Log("msg", "dynconf invalid RFC3339 date setting", "path", c.path, "setting", setting, "value", s, "err", err)
+	if _, _, err := net.SplitHostPort(s); err != nil {
+		c.logger.Log("msg", "dynconf invalid endpoint", "path", c.path, "setting", setting, "value", s, "err", err)
 		return defaultValue
 	}
 
-	return t
+	return s
 }
 
-// DateRequired returns the date value of the given setting,
-// or error if it wasn't found or RFC3339 parsing failed.
-func (c *Config) DateRequired(setting string, format string) (time.Time, error) {
-	v, ok := c.settings.Load(setting)
+// URL returns the URL value of the given setting,
+// or defaultValue if it wasn't found or parsing failed.
+// The URL must have a scheme and host if WithAbsoluteURLs is set.
+func (c *Config) URL(setting string, defaultValue *url.URL) *url.URL {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
-		return time.Time{}, fmt.Errorf("dynconf setting not found: %s", setting)
+		return defaultValue
 	}
 
 	s, ok := v.(string)
 	if !ok {
 		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
-		return time.Time{}, fmt.Errorf("dynconf invalid string value: %s", setting)
+		return defaultValue
 	}
 
-	t, err := time.Parse(format, s)
+	u, err := c.parseURL(s)
 	if err != nil {
-		c.logger.Log("msg", "dynconf invalid RFC3339 date setting", "path", c.path, "setting", setting, "value", s, "err", err)
-		return time.Time{}, fmt.Errorf("dynconf invalid RFC3339 date setting: %s", setting)
+		c.logger.Log("msg", "dynconf invalid URL setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
 	}
 
-	return t, nil
+	return u
 }
 
-// Struct returns the struct value of the given setting,
-func (c *Config) Struct(setting string, out interface{}) error {
-	v, ok := c.settings.Load(setting)
+// URLRequired returns the URL value of the given setting,
+// or error if it wasn't found or parsing failed.
+// The URL must have a scheme and host if WithAbsoluteURLs is set.
+func (c *Config) URLRequired(setting string) (*url.URL, error) {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
-		return errors.New("setting not found")
+		return nil, fmt.Errorf("dynconf setting not found: %s", setting)
 	}
 
 	s, ok := v.(string)
 	if !ok {
 		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
-		return errors.New("invalid string value")
+		return nil, fmt.Errorf("dynconf invalid string value: %s", setting)
 	}
 
-	if unmarshaler, ok := out.(json.Unmarshaler); ok && unmarshaler != nil {
-		return unmarshaler.UnmarshalJSON([]byte(s))
+	u, err := c.parseURL(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid URL setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return nil, fmt.Errorf("dynconf invalid URL setting: %s", setting)
 	}
 
-	return json.Unmarshal([]byte(s), out)
+	return u, nil
 }
 
-// Duration returns the duration value of the given setting,
+// parseURL parses the URL and makes sure it's absolute if WithAbsoluteURLs is set.
+func (c *Config) parseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if c.absoluteURLs && (u.Scheme == "" || u.Host == "") {
+		return nil, errors.New("scheme and host are required")
+	}
+
+	return u, nil
+}
+
+// IP returns the IPv4 or IPv6 address value of the given setting,
 // or defaultValue if it wasn't found or parsing failed.
-func (c *Config) Duration(setting string, defaultValue time.Duration) time.Duration {
-	v, ok := c.settings.Load(setting)
+func (c *Config) IP(setting string, defaultValue net.IP) net.IP {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
 		return defaultValue
@@ -488,42 +2650,43 @@ func (c *Config) Duration(setting string, defaultValue time.Duration) time.Durat
 		return defaultValue
 	}
 
-	d, err := time.ParseDuration(s)
-	if err != nil {
-		c.logger.Log("msg", "dynconf invalid duration setting", "path", c.path, "setting", setting, "value", s, "err", err)
+	ip := net.ParseIP(s)
+	if ip == nil {
+		c.logger.Log("msg", "dynconf invalid IP setting", "path", c.path, "setting", setting, "value", s, "err", "invalid IP address")
 		return defaultValue
 	}
 
-	return d
+	return ip
 }
 
-// DurationRequired returns the duration value of the given setting,
-// or error if it wasn't found or parsing failed.
-func (c *Config) DurationRequired(setting string) (time.Duration, error) {
-	v, ok := c.settings.Load(setting)
+// CIDR returns the network of the given setting in CIDR notation, e.g., 10.0.0.0/8,
+// or defaultValue if it wasn't found or parsing failed.
+func (c *Config) CIDR(setting string, defaultValue *net.IPNet) *net.IPNet {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
-		return 0, fmt.Errorf("dynconf setting not found: %s", setting)
+		return defaultValue
 	}
 
 	s, ok := v.(string)
 	if !ok {
 		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
-		return 0, fmt.Errorf("dynconf invalid string value: %s", setting)
+		return defaultValue
 	}
 
-	d, err := time.ParseDuration(s)
+	_, n, err := net.ParseCIDR(s)
 	if err != nil {
-		c.logger.Log("msg", "dynconf invalid duration setting", "path", c.path, "setting", setting, "value", s, "err", err)
-		return 0, fmt.Errorf("dynconf invalid duration setting: %s", setting)
+		c.logger.Log("msg", "dynconf invalid CIDR setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
 	}
 
-	return d, nil
+	return n
 }
 
-// StringArray returns the string array value of the given setting,
-func (c *Config) StringArray(setting string, delimiter string) []string {
-	v, ok := c.settings.Load(setting)
+// CIDRArray returns the networks of the given setting in CIDR notation split by delimiter.
+// Invalid networks are skipped. It returns nil if the setting wasn't found or its value is empty.
+func (c *Config) CIDRArray(setting string, delimiter string) []*net.IPNet {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
 		return nil
@@ -534,36 +2697,59 @@ func (c *Config) StringArray(setting string, delimiter string) []string {
 		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
 		return nil
 	}
+	if s == "" {
+		return nil
+	}
+
+	var ns []*net.IPNet
+	for _, e := range strings.Split(s, delimiter) {
+		_, n, err := net.ParseCIDR(e)
+		if err != nil {
+			c.logger.Log("msg", "dynconf invalid CIDR", "path", c.path, "setting", setting, "value", e, "err", err)
+			continue
+		}
+		ns = append(ns, n)
+	}
 
-	return strings.Split(s, delimiter)
+	return ns
 }
 
-// IntegerArray returns the integer array value of the given setting,
-func (c *Config) IntegerArray(setting string, delimiter string) []int {
-	v, ok := c.settings.Load(setting)
+// ArrayLen returns the number of elements in the array value of the given setting
+// without allocating the array. It returns 0 if the setting wasn't found or its value is empty.
+func (c *Config) ArrayLen(setting string, delimiter string) int {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
-		return nil
+		return 0
 	}
 
 	s, ok := v.(string)
 	if !ok {
 		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
-		return nil
+		return 0
 	}
 
-	ss := strings.Split(s, delimiter)
-	is := make([]int, len(ss))
-	for i, s := range ss {
-		is[i], _ = strconv.Atoi(s)
+	return arrayLen(s, delimiter)
+}
+
+// arrayLen returns the number of elements in the array value, 0 if the value is empty.
+// The value is split into the UTF-8 characters if the delimiter is empty, as strings.Split does.
+func arrayLen(s, delimiter string) int {
+	if s == "" {
+		return 0
+	}
+	if delimiter == "" {
+		return utf8.RuneCountInString(s)
 	}
 
-	return is
+	return strings.Count(s, delimiter) + 1
 }
 
-// FloatArray returns the float array value of the given setting,
-func (c *Config) FloatArray(setting string, delimiter string) []float64 {
-	v, ok := c.settings.Load(setting)
+// WeightedFloats returns the labeled float values of the given setting, e.g., "a:0.5,b:0.3,c:0.2"
+// where pairDelim separates the pairs and kvDelim separates a label from its weight.
+// Malformed pairs are skipped. It returns nil if the setting wasn't found.
+func (c *Config) WeightedFloats(setting, pairDelim, kvDelim string) map[string]float64 {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
 		return nil
@@ -575,18 +2761,39 @@ func (c *Config) FloatArray(setting string, delimiter string) []float64 {
 		return nil
 	}
 
-	ss := strings.Split(s, delimiter)
-	fs := make([]float64, len(ss))
-	for i, s := range ss {
-		fs[i], _ = strconv.ParseFloat(s, 64)
+	ws := make(map[string]float64)
+	var sum float64
+	for _, pair := range strings.Split(s, pairDelim) {
+		kv := strings.SplitN(pair, kvDelim, 2)
+		if len(kv) != 2 {
+			c.logger.Log("msg", "dynconf invalid weighted pair", "path", c.path, "setting", setting, "value", pair)
+			continue
+		}
+
+		f, err := strconv.ParseFloat(kv[1], 64)
+		if err != nil {
+			c.logger.Log("msg", "dynconf invalid weighted pair", "path", c.path, "setting", setting, "value", pair, "err", err)
+			continue
+		}
+
+		ws[kv[0]] = f
+		sum += f
 	}
 
-	return fs
+	if c.normalizeWeights && sum != 0 {
+		for k := range ws {
+			ws[k] /= sum
+		}
+	}
+
+	return ws
 }
 
-// DateArray returns the date array value of the given setting,
-func (c *Config) DateArray(setting string, format string, delimiter string) []time.Time {
-	v, ok := c.settings.Load(setting)
+// Map returns the key-value pairs of the given setting, e.g., "us=10,eu=20"
+// where pairDelim separates the pairs and kvDelim separates a key from its value.
+// Malformed pairs are skipped. It returns nil if the setting wasn't found.
+func (c *Config) Map(setting, pairDelim, kvDelim string) map[string]string {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
 		return nil
@@ -598,34 +2805,49 @@ func (c *Config) DateArray(setting string, format string, delimiter string) []ti
 		return nil
 	}
 
-	ss := strings.Split(s, delimiter)
-	ts := make([]time.Time, len(ss))
-	for i, s := range ss {
-		ts[i], _ = time.Parse(format, s)
+	m := make(map[string]string)
+	if s == "" {
+		return m
+	}
+	for _, pair := range strings.Split(s, pairDelim) {
+		kv := strings.SplitN(pair, kvDelim, 2)
+		if len(kv) != 2 {
+			c.logger.Log("msg", "dynconf invalid map pair", "path", c.path, "setting", setting, "value", pair)
+			continue
+		}
+		m[kv[0]] = kv[1]
 	}
 
-	return ts
+	return m
 }
 
-// BooleanArray returns the boolean array value of the given setting,
-func (c *Config) BooleanArray(setting string, delimiter string) []bool {
-	v, ok := c.settings.Load(setting)
+// MapRequired returns the key-value pairs of the given setting,
+// or error if it wasn't found or any of the pairs is malformed.
+func (c *Config) MapRequired(setting, pairDelim, kvDelim string) (map[string]string, error) {
+	v, ok := c.lookup(setting)
 	if !ok {
 		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
-		return nil
+		return nil, fmt.Errorf("dynconf setting not found: %s", setting)
 	}
 
 	s, ok := v.(string)
 	if !ok {
 		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
-		return nil
+		return nil, fmt.Errorf("dynconf invalid string value: %s", setting)
 	}
 
-	ss := strings.Split(s, delimiter)
-	bs := make([]bool, len(ss))
-	for i, s := range ss {
-		bs[i], _ = strconv.ParseBool(s)
+	m := make(map[string]string)
+	if s == "" {
+		return m, nil
+	}
+	for i, pair := range strings.Split(s, pairDelim) {
+		kv := strings.SplitN(pair, kvDelim, 2)
+		if len(kv) != 2 {
+			c.logger.Log("msg", "dynconf invalid map pair", "path", c.path, "setting", setting, "value", pair)
+			return nil, fmt.Errorf("dynconf invalid map setting %s at index %d: %q", setting, i, pair)
+		}
+		m[kv[0]] = kv[1]
 	}
 
-	return bs
+	return m, nil
 }
@@ -1,7 +1,15 @@
 package dynconf
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"math"
+	"math/big"
+	"net"
+	"net/url"
 	"os"
 	"reflect"
 	"strconv"
@@ -9,8 +17,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/coreos/go-semver/semver"
 	"github.com/go-kit/log"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/vmihailenco/msgpack/v5"
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
@@ -73,6 +85,34 @@ func TestConfigString(t *testing.T) {
 	}
 }
 
+func TestConfigLookup(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if _, ok := c.Lookup("name"); ok {
+		t.Error("expected name not to be found")
+	}
+
+	c.settings.Store("name", "")
+	got, ok := c.Lookup("name")
+	if !ok || got != "" {
+		t.Errorf("expected empty name got %q %t", got, ok)
+	}
+
+	c.settings.Store("name", "alice")
+	if got, _ = c.Lookup("name"); got != "alice" {
+		t.Errorf("expected %q got %q", "alice", got)
+	}
+}
+
 func TestConfigStringRequired(t *testing.T) {
 	tests := map[string]struct {
 		in      interface{}
@@ -137,44 +177,30 @@ func TestConfigStringRequired(t *testing.T) {
 	}
 }
 
-func TestConfigBoolean(t *testing.T) {
-	const defaultIsCameraEnabled = false
-
+func TestConfigTextBlock(t *testing.T) {
 	tests := map[string]struct {
 		in   interface{}
-		want bool
+		want string
 	}{
-		"string bool": {
-			in:   "false",
-			want: false,
-		},
-		"string int": {
-			in:   "10",
-			want: defaultIsCameraEnabled,
-		},
-		"string float": {
-			in:   "0.001",
-			want: defaultIsCameraEnabled,
+		"CRLF": {
+			in:   "-----BEGIN CERTIFICATE-----\r\nMIIB\r\n-----END CERTIFICATE-----\r\n",
+			want: "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n",
 		},
-		"string name": {
-			in:   "alice",
-			want: defaultIsCameraEnabled,
+		"trailing blank line": {
+			in:   "line1\r\nline2\r\n\r\n",
+			want: "line1\nline2\n",
 		},
-		"bytes": {
-			in:   []byte("alice"),
-			want: defaultIsCameraEnabled,
+		"CR": {
+			in:   "line1\rline2",
+			want: "line1\nline2",
 		},
-		"nil": {
-			in:   nil,
-			want: defaultIsCameraEnabled,
+		"LF": {
+			in:   "line1\nline2\n",
+			want: "line1\nline2\n",
 		},
 		"int": {
 			in:   100,
-			want: defaultIsCameraEnabled,
-		},
-		"float": {
-			in:   0.001,
-			want: defaultIsCameraEnabled,
+			want: "",
 		},
 	}
 
@@ -190,54 +216,49 @@ func TestConfigBoolean(t *testing.T) {
 	})
 
 	t.Run("no key", func(t *testing.T) {
-		got := c.Boolean("is_camera_enabled", defaultIsCameraEnabled)
-		want := defaultIsCameraEnabled
-		if want != got {
-			t.Errorf("expected %t got %t", want, got)
+		if got := c.TextBlock("cert"); got != "" {
+			t.Errorf("expected empty string got %q", got)
 		}
 	})
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			c.settings.Store("is_camera_enabled", tc.in)
-			got := c.Boolean("is_camera_enabled", defaultIsCameraEnabled)
+			c.settings.Store("cert", tc.in)
+			got := c.TextBlock("cert")
 			if tc.want != got {
-				t.Errorf("expected %t got %t", tc.want, got)
+				t.Errorf("expected %q got %q", tc.want, got)
 			}
 		})
 	}
 }
 
-func TestConfigInteger(t *testing.T) {
-	const defaultVelocity = 10
+func TestConfigEnum(t *testing.T) {
+	const defaultMode = "off"
+	allowed := []string{"off", "shadow", "enforce"}
 
 	tests := map[string]struct {
 		in   interface{}
-		want int
+		want string
 	}{
-		"string int": {
-			in:   "10",
-			want: 10,
+		"allowed": {
+			in:   "shadow",
+			want: "shadow",
 		},
-		"string name": {
-			in:   "alice",
-			want: defaultVelocity,
+		"not allowed": {
+			in:   "audit",
+			want: defaultMode,
 		},
-		"bytes": {
-			in:   []byte("alice"),
-			want: defaultVelocity,
+		"case sensitive": {
+			in:   "Enforce",
+			want: defaultMode,
 		},
-		"nil": {
-			in:   nil,
-			want: defaultVelocity,
+		"empty": {
+			in:   "",
+			want: defaultMode,
 		},
 		"int": {
-			in:   100,
-			want: defaultVelocity,
-		},
-		"float": {
-			in:   0.001,
-			want: defaultVelocity,
+			in:   1,
+			want: defaultMode,
 		},
 	}
 
@@ -253,54 +274,47 @@ func TestConfigInteger(t *testing.T) {
 	})
 
 	t.Run("no key", func(t *testing.T) {
-		got := c.Integer("velocity", defaultVelocity)
-		want := defaultVelocity
-		if want != got {
-			t.Errorf("expected %d got %d", want, got)
+		if got := c.Enum("mode", allowed, defaultMode); got != defaultMode {
+			t.Errorf("expected %s got %s", defaultMode, got)
 		}
 	})
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			c.settings.Store("velocity", tc.in)
-			got := c.Integer("velocity", defaultVelocity)
-			if tc.want != got {
-				t.Errorf("expected %d got %d", tc.want, got)
+			c.settings.Store("mode", tc.in)
+			if got := c.Enum("mode", allowed, defaultMode); tc.want != got {
+				t.Errorf("expected %s got %s", tc.want, got)
 			}
 		})
 	}
 }
 
-func TestConfigInt64(t *testing.T) {
-	const defaultVelocity int64 = 10
+func TestConfigBytes(t *testing.T) {
+	defaultKey := []byte("secret")
 
 	tests := map[string]struct {
 		in   interface{}
-		want int64
+		want []byte
 	}{
-		"string int": {
-			in:   "10",
-			want: 10,
-		},
-		"string name": {
-			in:   "alice",
-			want: defaultVelocity,
+		"base64": {
+			in:   "AAEC/w==",
+			want: []byte{0, 1, 2, 255},
 		},
-		"bytes": {
-			in:   []byte("alice"),
-			want: defaultVelocity,
+		"empty": {
+			in:   "",
+			want: []byte{},
 		},
-		"nil": {
-			in:   nil,
-			want: defaultVelocity,
+		"url encoding": {
+			in:   "AAEC_w==",
+			want: defaultKey,
 		},
-		"int": {
-			in:   100,
-			want: defaultVelocity,
+		"missing padding": {
+			in:   "AAEC/w",
+			want: defaultKey,
 		},
-		"float": {
-			in:   0.001,
-			want: defaultVelocity,
+		"bytes": {
+			in:   []byte{0, 1},
+			want: defaultKey,
 		},
 	}
 
@@ -315,59 +329,43 @@ func TestConfigInt64(t *testing.T) {
 		}
 	})
 
-	t.Run("no key", func(t *testing.T) {
-		got := c.Int64("velocity", defaultVelocity)
-		want := defaultVelocity
-		if want != got {
-			t.Errorf("expected %d got %d", want, got)
-		}
-	})
-
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			c.settings.Store("velocity", tc.in)
-			got := c.Int64("velocity", defaultVelocity)
-			if tc.want != got {
-				t.Errorf("expected %d got %d", tc.want, got)
+			c.settings.Store("signing_key", tc.in)
+			got := c.Bytes("signing_key", defaultKey)
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("expected %v got %v", tc.want, got)
 			}
 		})
 	}
 }
 
-func TestConfigFloat(t *testing.T) {
-	const defaultTemperature = 36.6
+func TestConfigHexBytes(t *testing.T) {
+	defaultChecksum := []byte{0}
 
 	tests := map[string]struct {
 		in   interface{}
-		want float64
+		want []byte
 	}{
-		"string int": {
-			in:   "10",
-			want: 10,
-		},
-		"string float": {
-			in:   "10.1",
-			want: 10.1,
+		"lowercase": {
+			in:   "00ff10",
+			want: []byte{0, 255, 16},
 		},
-		"string name": {
-			in:   "alice",
-			want: defaultTemperature,
+		"uppercase": {
+			in:   "00FF10",
+			want: []byte{0, 255, 16},
 		},
-		"bytes": {
-			in:   []byte("alice"),
-			want: defaultTemperature,
+		"odd length": {
+			in:   "00f",
+			want: defaultChecksum,
 		},
-		"nil": {
-			in:   nil,
-			want: defaultTemperature,
+		"invalid digit": {
+			in:   "0g",
+			want: defaultChecksum,
 		},
 		"int": {
 			in:   100,
-			want: defaultTemperature,
-		},
-		"float": {
-			in:   0.001,
-			want: defaultTemperature,
+			want: defaultChecksum,
 		},
 	}
 
@@ -382,63 +380,52 @@ func TestConfigFloat(t *testing.T) {
 		}
 	})
 
-	t.Run("no key", func(t *testing.T) {
-		got := c.Float("temperature", defaultTemperature)
-		want := defaultTemperature
-		if want != got {
-			t.Errorf("expected %f got %f", want, got)
-		}
-	})
-
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			c.settings.Store("temperature", tc.in)
-			got := c.Float("temperature", defaultTemperature)
-			if tc.want != got {
-				t.Errorf("expected %f got %f", tc.want, got)
+			c.settings.Store("checksum", tc.in)
+			got := c.HexBytes("checksum", defaultChecksum)
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("expected %v got %v", tc.want, got)
 			}
 		})
 	}
 }
 
-func TestConfigDate(t *testing.T) {
-	defaultLaunchedDate, _ := time.Parse(time.RFC3339, "2021-11-30T20:14:05.134115+00:00")
+func TestConfigUUID(t *testing.T) {
+	defaultTenant := uuid.MustParse("00000000-0000-4000-8000-000000000000")
 
 	tests := map[string]struct {
-		in   interface{}
-		want time.Time
+		in      interface{}
+		want    uuid.UUID
+		wantErr bool
 	}{
-		"string date": {
-			in:   "2021-11-30T21:14:05.134115+00:00",
-			want: defaultLaunchedDate.Add(time.Hour),
-		},
-		"string int": {
-			in:   "10",
-			want: defaultLaunchedDate,
+		"v4": {
+			in:   "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+			want: uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479"),
 		},
-		"string float": {
-			in:   "10.1",
-			want: defaultLaunchedDate,
+		"uppercase": {
+			in:   "F47AC10B-58CC-4372-A567-0E02B2C3D479",
+			want: uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479"),
 		},
-		"string name": {
-			in:   "alice",
-			want: defaultLaunchedDate,
+		"nil uuid": {
+			in:      "00000000-0000-0000-0000-000000000000",
+			want:    defaultTenant,
+			wantErr: true,
 		},
-		"bytes": {
-			in:   []byte("alice"),
-			want: defaultLaunchedDate,
+		"urn": {
+			in:      "urn:uuid:f47ac10b-58cc-4372-a567-0e02b2c3d479",
+			want:    defaultTenant,
+			wantErr: true,
 		},
-		"nil": {
-			in:   nil,
-			want: defaultLaunchedDate,
+		"without hyphens": {
+			in:      "f47ac10b58cc4372a5670e02b2c3d479",
+			want:    defaultTenant,
+			wantErr: true,
 		},
 		"int": {
-			in:   100,
-			want: defaultLaunchedDate,
-		},
-		"float": {
-			in:   0.001,
-			want: defaultLaunchedDate,
+			in:      100,
+			want:    defaultTenant,
+			wantErr: true,
 		},
 	}
 
@@ -454,50 +441,52 @@ func TestConfigDate(t *testing.T) {
 	})
 
 	t.Run("no key", func(t *testing.T) {
-		got := c.Date("launched_at", time.RFC3339, defaultLaunchedDate)
-		want := defaultLaunchedDate
-		if !want.Equal(got) {
-			t.Errorf("expected %s got %s", want, got)
+		if _, err := c.UUIDRequired("tenant_id"); err == nil {
+			t.Error("expected error")
 		}
 	})
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			c.settings.Store("launched_at", tc.in)
-			got := c.Date("launched_at", time.RFC3339, defaultLaunchedDate)
-			if !tc.want.Equal(got) {
+			c.settings.Store("tenant_id", tc.in)
+			if got := c.UUID("tenant_id", defaultTenant); tc.want != got {
 				t.Errorf("expected %s got %s", tc.want, got)
 			}
+
+			_, err := c.UUIDRequired("tenant_id")
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error %t got %v", tc.wantErr, err)
+			}
 		})
 	}
 }
 
-func TestConfigStruct(t *testing.T) {
-	type config struct {
-		Name   string  `json:"name"`
-		Age    int     `json:"age"`
-		Weight float64 `json:"weight"`
-	}
+func TestConfigSemVer(t *testing.T) {
+	defaultMinVersion := semver.Version{Major: 1}
 
 	tests := map[string]struct {
 		in   interface{}
-		want config
+		want semver.Version
 	}{
-		"string int": {
-			in: "{\"age\":10}",
-			want: config{
-				Age: 10,
-			},
+		"version": {
+			in:   "1.4.2",
+			want: semver.Version{Major: 1, Minor: 4, Patch: 2},
 		},
-		"string float": {
-			in: "{\"weight\":10.1}",
-			want: config{
-				Weight: 10.1,
-			},
+		"v prefix and pre-release": {
+			in:   "v2.0.0-rc.1",
+			want: semver.Version{Major: 2, PreRelease: "rc.1"},
 		},
-		"string name": {
-			in:   "{\"name\":\"alice\"}",
-			want: config{Name: "alice"},
+		"missing patch": {
+			in:   "1.4",
+			want: defaultMinVersion,
+		},
+		"non-numeric": {
+			in:   "1.x.0",
+			want: defaultMinVersion,
+		},
+		"int": {
+			in:   1,
+			want: defaultMinVersion,
 		},
 	}
 
@@ -514,79 +503,57 @@ func TestConfigStruct(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			c.settings.Store("config", tc.in)
-			var got config
-			if err := c.Struct("config", &got); err != nil {
-				t.Fatal(err)
-			}
-			if !reflect.DeepEqual(tc.want, got) {
-				t.Errorf("expected %v got %v", tc.want, got)
+			c.settings.Store("min_client_version", tc.in)
+			if got := c.SemVer("min_client_version", defaultMinVersion); !tc.want.Equal(got) {
+				t.Errorf("expected %s got %s", tc.want, got)
 			}
 		})
 	}
-}
 
-type configWithUnmarshaler struct {
-	Name   string
-	Age    int
-	Weight float64
+	c.settings.Store("min_client_version", "1.10.0")
+	if min := c.SemVer("min_client_version", defaultMinVersion); !min.LessThan(*semver.New("1.10.1")) {
+		t.Errorf("expected %s to be less than 1.10.1", min)
+	}
 }
 
-func (c *configWithUnmarshaler) UnmarshalJSON(data []byte) error {
-	split := strings.Split(string(data), ",")
-	conf := configWithUnmarshaler{}
-	for _, d := range split {
-		parts := strings.Split(d, ":")
-		switch strings.Trim(parts[0], "\"") {
-		case "name":
-			conf.Name = strings.Trim(parts[1], "\"")
-		case "age":
-			age, err := strconv.Atoi(strings.Trim(parts[1], "\""))
-			if err != nil {
-				return err
-			}
-			conf.Age = age
-		case "weight":
-			weight, err := strconv.ParseFloat(strings.Trim(parts[1], "\""), 64)
-			if err != nil {
-				return err
-			}
-			conf.Weight = weight
-		}
-	}
-
-	*c = conf
-	return nil
-}
+func TestConfigBoolean(t *testing.T) {
+	const defaultIsCameraEnabled = false
 
-func TestConfigStructCustomUnmarshaler(t *testing.T) {
 	tests := map[string]struct {
 		in   interface{}
-		want configWithUnmarshaler
+		want bool
 	}{
+		"string bool": {
+			in:   "false",
+			want: false,
+		},
 		"string int": {
-			in: "age:10",
-			want: configWithUnmarshaler{
-				Age: 10,
-			},
+			in:   "10",
+			want: defaultIsCameraEnabled,
 		},
 		"string float": {
-			in: "weight:10.1",
-			want: configWithUnmarshaler{
-				Weight: 10.1,
-			},
+			in:   "0.001",
+			want: defaultIsCameraEnabled,
 		},
 		"string name": {
-			in:   "name:alice",
-			want: configWithUnmarshaler{Name: "alice"},
+			in:   "alice",
+			want: defaultIsCameraEnabled,
 		},
-		"multi key": {
-			in: "name:alice,age:10,weight:10.1",
-			want: configWithUnmarshaler{
-				Name:   "alice",
-				Age:    10,
-				Weight: 10.1,
-			},
+		"bytes": {
+			in:   []byte("alice"),
+			want: defaultIsCameraEnabled,
+		},
+		"nil": {
+			in:   nil,
+			want: defaultIsCameraEnabled,
+		},
+		"int": {
+			in:   100,
+			want: defaultIsCameraEnabled,
+		},
+		"float": {
+			in:   0.001,
+			want: defaultIsCameraEnabled,
 		},
 	}
 
@@ -601,40 +568,55 @@ func TestConfigStructCustomUnmarshaler(t *testing.T) {
 		}
 	})
 
+	t.Run("no key", func(t *testing.T) {
+		got := c.Boolean("is_camera_enabled", defaultIsCameraEnabled)
+		want := defaultIsCameraEnabled
+		if want != got {
+			t.Errorf("expected %t got %t", want, got)
+		}
+	})
+
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			c.settings.Store("config", tc.in)
-			var got configWithUnmarshaler
-			if err := c.Struct("config", &got); err != nil {
-				t.Fatal(err)
-			}
-			if !reflect.DeepEqual(tc.want, got) {
-				t.Errorf("expected %v got %v", tc.want, got)
+			c.settings.Store("is_camera_enabled", tc.in)
+			got := c.Boolean("is_camera_enabled", defaultIsCameraEnabled)
+			if tc.want != got {
+				t.Errorf("expected %t got %t", tc.want, got)
 			}
 		})
 	}
 }
 
-func TestConfigDuration(t *testing.T) {
+func TestConfigInteger(t *testing.T) {
+	const defaultVelocity = 10
+
 	tests := map[string]struct {
 		in   interface{}
-		want time.Duration
+		want int
 	}{
-		"duration second": {
-			in:   "10s",
-			want: 10 * time.Second,
+		"string int": {
+			in:   "10",
+			want: 10,
 		},
-		"duration minute": {
-			in:   "10m",
-			want: 10 * time.Minute,
+		"string name": {
+			in:   "alice",
+			want: defaultVelocity,
 		},
-		"duration hour": {
-			in:   "10h",
-			want: 10 * time.Hour,
+		"bytes": {
+			in:   []byte("alice"),
+			want: defaultVelocity,
 		},
-		"no duration": {
-			in:   "10",
-			want: 5 * time.Second,
+		"nil": {
+			in:   nil,
+			want: defaultVelocity,
+		},
+		"int": {
+			in:   100,
+			want: defaultVelocity,
+		},
+		"float": {
+			in:   0.001,
+			want: defaultVelocity,
 		},
 	}
 
@@ -649,32 +631,130 @@ func TestConfigDuration(t *testing.T) {
 		}
 	})
 
+	t.Run("no key", func(t *testing.T) {
+		got := c.Integer("velocity", defaultVelocity)
+		want := defaultVelocity
+		if want != got {
+			t.Errorf("expected %d got %d", want, got)
+		}
+	})
+
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			c.settings.Store("duration", tc.in)
-			got := c.Duration("duration", 5*time.Second)
+			c.settings.Store("velocity", tc.in)
+			got := c.Integer("velocity", defaultVelocity)
 			if tc.want != got {
-				t.Errorf("expected %s got %s", tc.want, got)
+				t.Errorf("expected %d got %d", tc.want, got)
 			}
 		})
 	}
 }
 
-func TestConfigStringArray(t *testing.T) {
+func TestConfigIntegerInRange(t *testing.T) {
+	tests := map[string]struct {
+		in     interface{}
+		reject bool
+		want   int
+	}{
+		"in range":           {in: "5", want: 5},
+		"min":                {in: "1", want: 1},
+		"below min":          {in: "-5", want: 1},
+		"above max":          {in: "50", want: 10},
+		"below min rejected": {in: "-5", reject: true, want: 3},
+		"above max rejected": {in: "50", reject: true, want: 3},
+		"not numeric":        {in: "fast", want: 3},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := []Option{WithLogger(logger)}
+			if tc.reject {
+				opts = append(opts, WithRejectOutOfRange())
+			}
+			c, err := New("/configs/curiosity/", opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			c.settings.Store("velocity", tc.in)
+			got := c.IntegerInRange("velocity", 1, 10, 3)
+			if tc.want != got {
+				t.Errorf("expected %d got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigFloatInRange(t *testing.T) {
+	tests := map[string]struct {
+		in     interface{}
+		reject bool
+		want   float64
+	}{
+		"in range":           {in: "0.5", want: 0.5},
+		"below min":          {in: "-0.5", want: 0},
+		"above max":          {in: "1.5", want: 1},
+		"above max rejected": {in: "1.5", reject: true, want: 0.1},
+		"not numeric":        {in: "half", want: 0.1},
+		"NaN":                {in: "NaN", want: 0.1},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := []Option{WithLogger(logger)}
+			if tc.reject {
+				opts = append(opts, WithRejectOutOfRange())
+			}
+			c, err := New("/configs/curiosity/", opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			c.settings.Store("ratio", tc.in)
+			got := c.FloatInRange("ratio", 0, 1, 0.1)
+			if tc.want != got {
+				t.Errorf("expected %f got %f", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigInt64(t *testing.T) {
+	const defaultVelocity int64 = 10
+
 	tests := map[string]struct {
 		in   interface{}
-		del  string
-		want []string
+		want int64
 	}{
-		"string array": {
-			in:   "alice,bob",
-			del:  ",",
-			want: []string{"alice", "bob"},
+		"string int": {
+			in:   "10",
+			want: 10,
 		},
-		"string array with different separator": {
-			in:   "alice|bob",
-			del:  "|",
-			want: []string{"alice", "bob"},
+		"string name": {
+			in:   "alice",
+			want: defaultVelocity,
+		},
+		"bytes": {
+			in:   []byte("alice"),
+			want: defaultVelocity,
+		},
+		"nil": {
+			in:   nil,
+			want: defaultVelocity,
+		},
+		"int": {
+			in:   100,
+			want: defaultVelocity,
+		},
+		"float": {
+			in:   0.001,
+			want: defaultVelocity,
 		},
 	}
 
@@ -689,37 +769,2648 @@ func TestConfigStringArray(t *testing.T) {
 		}
 	})
 
+	t.Run("no key", func(t *testing.T) {
+		got := c.Int64("velocity", defaultVelocity)
+		want := defaultVelocity
+		if want != got {
+			t.Errorf("expected %d got %d", want, got)
+		}
+	})
+
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			c.settings.Store("names", tc.in)
-			got := c.StringArray("names", tc.del)
-			if !reflect.DeepEqual(tc.want, got) {
-				t.Errorf("expected %v got %v", tc.want, got)
+			c.settings.Store("velocity", tc.in)
+			got := c.Int64("velocity", defaultVelocity)
+			if tc.want != got {
+				t.Errorf("expected %d got %d", tc.want, got)
 			}
 		})
 	}
 }
 
-func TestConfigIntegerArray(t *testing.T) {
+func TestConfigUint64(t *testing.T) {
+	const defaultMemoryLimit uint64 = 1024
+
 	tests := map[string]struct {
 		in   interface{}
-		del  string
-		want []int
+		want uint64
 	}{
-		"string array": {
-			in:   "10,20",
-			del:  ",",
-			want: []int{10, 20},
+		"string int": {
+			in:   "2048",
+			want: 2048,
+		},
+		"max uint64": {
+			in:   "18446744073709551615",
+			want: math.MaxUint64,
+		},
+		"negative": {
+			in:   "-1",
+			want: defaultMemoryLimit,
+		},
+		"string name": {
+			in:   "alice",
+			want: defaultMemoryLimit,
+		},
+		"nil": {
+			in:   nil,
+			want: defaultMemoryLimit,
+		},
+		"int": {
+			in:   100,
+			want: defaultMemoryLimit,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		got := c.Uint64("memory_limit", defaultMemoryLimit)
+		want := defaultMemoryLimit
+		if want != got {
+			t.Errorf("expected %d got %d", want, got)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("memory_limit", tc.in)
+			got := c.Uint64("memory_limit", defaultMemoryLimit)
+			if tc.want != got {
+				t.Errorf("expected %d got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigUintRequired(t *testing.T) {
+	tests := map[string]struct {
+		in      interface{}
+		want    uint
+		wantErr bool
+	}{
+		"string int": {
+			in:   "8080",
+			want: 8080,
+		},
+		"negative": {
+			in:      "-8080",
+			wantErr: true,
+		},
+		"float": {
+			in:      "80.5",
+			wantErr: true,
+		},
+		"int": {
+			in:      8080,
+			wantErr: true,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		if _, err := c.UintRequired("port"); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("port", tc.in)
+			got, err := c.UintRequired("port")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %t got %v", tc.wantErr, err)
+			}
+			if tc.want != got {
+				t.Errorf("expected %d got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigInt32(t *testing.T) {
+	const defaultVelocity int32 = 10
+
+	tests := map[string]struct {
+		in   interface{}
+		want int32
+	}{
+		"string int": {
+			in:   "-10",
+			want: -10,
+		},
+		"max int32": {
+			in:   "2147483647",
+			want: math.MaxInt32,
+		},
+		"overflow": {
+			in:   "2147483648",
+			want: defaultVelocity,
+		},
+		"string name": {
+			in:   "alice",
+			want: defaultVelocity,
+		},
+		"int": {
+			in:   100,
+			want: defaultVelocity,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("velocity", tc.in)
+			got := c.Int32("velocity", defaultVelocity)
+			if tc.want != got {
+				t.Errorf("expected %d got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigUint32(t *testing.T) {
+	const defaultPort uint32 = 8080
+
+	tests := map[string]struct {
+		in   interface{}
+		want uint32
+	}{
+		"string int": {
+			in:   "9090",
+			want: 9090,
+		},
+		"max uint32": {
+			in:   "4294967295",
+			want: math.MaxUint32,
+		},
+		"overflow": {
+			in:   "4294967296",
+			want: defaultPort,
+		},
+		"negative": {
+			in:   "-1",
+			want: defaultPort,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("port", tc.in)
+			got := c.Uint32("port", defaultPort)
+			if tc.want != got {
+				t.Errorf("expected %d got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigFloat(t *testing.T) {
+	const defaultTemperature = 36.6
+
+	tests := map[string]struct {
+		in   interface{}
+		want float64
+	}{
+		"string int": {
+			in:   "10",
+			want: 10,
+		},
+		"string float": {
+			in:   "10.1",
+			want: 10.1,
+		},
+		"string name": {
+			in:   "alice",
+			want: defaultTemperature,
+		},
+		"bytes": {
+			in:   []byte("alice"),
+			want: defaultTemperature,
+		},
+		"nil": {
+			in:   nil,
+			want: defaultTemperature,
+		},
+		"int": {
+			in:   100,
+			want: defaultTemperature,
+		},
+		"float": {
+			in:   0.001,
+			want: defaultTemperature,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		got := c.Float("temperature", defaultTemperature)
+		want := defaultTemperature
+		if want != got {
+			t.Errorf("expected %f got %f", want, got)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("temperature", tc.in)
+			got := c.Float("temperature", defaultTemperature)
+			if tc.want != got {
+				t.Errorf("expected %f got %f", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigFloat32(t *testing.T) {
+	const defaultLearningRate float32 = 0.01
+
+	tests := map[string]struct {
+		in   interface{}
+		want float32
+	}{
+		"string float": {
+			in:   "0.5",
+			want: 0.5,
+		},
+		"max float32": {
+			in:   "3.4028234663852886e+38",
+			want: math.MaxFloat32,
+		},
+		"overflow": {
+			in:   "1e39",
+			want: defaultLearningRate,
+		},
+		"string name": {
+			in:   "alice",
+			want: defaultLearningRate,
+		},
+		"float": {
+			in:   0.5,
+			want: defaultLearningRate,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		if _, err := c.Float32Required("learning_rate"); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("learning_rate", tc.in)
+			got := c.Float32("learning_rate", defaultLearningRate)
+			if tc.want != got {
+				t.Errorf("expected %f got %f", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigPercent(t *testing.T) {
+	const defaultSampleRate = 0.01
+
+	tests := map[string]struct {
+		in   interface{}
+		want float64
+	}{
+		"percent sign": {
+			in:   "15%",
+			want: 0.15,
+		},
+		"percentage": {
+			in:   "15",
+			want: 0.15,
+		},
+		"fraction": {
+			in:   "0.15",
+			want: 0.15,
+		},
+		"one": {
+			in:   "1",
+			want: 1,
+		},
+		"one percent": {
+			in:   "1%",
+			want: 0.01,
+		},
+		"above 100": {
+			in:   "150%",
+			want: 1,
+		},
+		"negative": {
+			in:   "-5%",
+			want: 0,
+		},
+		"NaN": {
+			in:   "NaN",
+			want: defaultSampleRate,
+		},
+		"percent sign only": {
+			in:   "%",
+			want: defaultSampleRate,
+		},
+		"float": {
+			in:   0.15,
+			want: defaultSampleRate,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("sample_rate", tc.in)
+			if got := c.Percent("sample_rate", defaultSampleRate); math.Abs(tc.want-got) > 1e-9 {
+				t.Errorf("expected %f got %f", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigDecimal(t *testing.T) {
+	defaultFee := decimal.RequireFromString("0.30")
+
+	tests := map[string]struct {
+		in   interface{}
+		want string
+	}{
+		"price": {
+			in:   "19.99",
+			want: "19.99",
+		},
+		"exact": {
+			in:   "0.1000000000000000000000000001",
+			want: "0.1000000000000000000000000001",
+		},
+		"negative": {
+			in:   "-5",
+			want: "-5",
+		},
+		"currency sign": {
+			in:   "$19.99",
+			want: "0.3",
+		},
+		"float": {
+			in:   19.99,
+			want: "0.3",
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("fee", tc.in)
+			if got := c.Decimal("fee", defaultFee).String(); tc.want != got {
+				t.Errorf("expected %s got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigBigInt(t *testing.T) {
+	defaultSupply := big.NewInt(1000)
+
+	tests := map[string]struct {
+		in      interface{}
+		want    string
+		wantErr bool
+	}{
+		"exceeds int64": {
+			in:   "123456789012345678901234567890",
+			want: "123456789012345678901234567890",
+		},
+		"negative": {
+			in:   "-42",
+			want: "-42",
+		},
+		"float": {
+			in:      "1.5",
+			want:    "1000",
+			wantErr: true,
+		},
+		"hex": {
+			in:      "0xff",
+			want:    "1000",
+			wantErr: true,
+		},
+		"int": {
+			in:      42,
+			want:    "1000",
+			wantErr: true,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		if _, err := c.BigIntRequired("token_supply"); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("token_supply", tc.in)
+			if got := c.BigInt("token_supply", defaultSupply).String(); tc.want != got {
+				t.Errorf("expected %s got %s", tc.want, got)
+			}
+
+			_, err := c.BigIntRequired("token_supply")
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error %t got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConfigFileMode(t *testing.T) {
+	const defaultMode os.FileMode = 0600
+
+	tests := map[string]struct {
+		in   interface{}
+		want os.FileMode
+	}{
+		"octal": {
+			in:   "0640",
+			want: 0640,
+		},
+		"without leading zero": {
+			in:   "755",
+			want: 0755,
+		},
+		"0o prefix": {
+			in:   "0o660",
+			want: 0660,
+		},
+		"decimal digit": {
+			in:   "0680",
+			want: defaultMode,
+		},
+		"setuid": {
+			in:   "4755",
+			want: defaultMode,
+		},
+		"symbolic": {
+			in:   "rw-r-----",
+			want: defaultMode,
+		},
+		"int": {
+			in:   0640,
+			want: defaultMode,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("socket_mode", tc.in)
+			if got := c.FileMode("socket_mode", defaultMode); tc.want != got {
+				t.Errorf("expected %s got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigDate(t *testing.T) {
+	defaultLaunchedDate, _ := time.Parse(time.RFC3339, "2021-11-30T20:14:05.134115+00:00")
+
+	tests := map[string]struct {
+		in   interface{}
+		want time.Time
+	}{
+		"string date": {
+			in:   "2021-11-30T21:14:05.134115+00:00",
+			want: defaultLaunchedDate.Add(time.Hour),
+		},
+		"string int": {
+			in:   "10",
+			want: defaultLaunchedDate,
+		},
+		"string float": {
+			in:   "10.1",
+			want: defaultLaunchedDate,
+		},
+		"string name": {
+			in:   "alice",
+			want: defaultLaunchedDate,
+		},
+		"bytes": {
+			in:   []byte("alice"),
+			want: defaultLaunchedDate,
+		},
+		"nil": {
+			in:   nil,
+			want: defaultLaunchedDate,
+		},
+		"int": {
+			in:   100,
+			want: defaultLaunchedDate,
+		},
+		"float": {
+			in:   0.001,
+			want: defaultLaunchedDate,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		got := c.Date("launched_at", time.RFC3339, defaultLaunchedDate)
+		want := defaultLaunchedDate
+		if !want.Equal(got) {
+			t.Errorf("expected %s got %s", want, got)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("launched_at", tc.in)
+			got := c.Date("launched_at", time.RFC3339, defaultLaunchedDate)
+			if !tc.want.Equal(got) {
+				t.Errorf("expected %s got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigStruct(t *testing.T) {
+	type config struct {
+		Name   string  `json:"name"`
+		Age    int     `json:"age"`
+		Weight float64 `json:"weight"`
+	}
+
+	tests := map[string]struct {
+		in   interface{}
+		want config
+	}{
+		"string int": {
+			in: "{\"age\":10}",
+			want: config{
+				Age: 10,
+			},
+		},
+		"string float": {
+			in: "{\"weight\":10.1}",
+			want: config{
+				Weight: 10.1,
+			},
+		},
+		"string name": {
+			in:   "{\"name\":\"alice\"}",
+			want: config{Name: "alice"},
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("config", tc.in)
+			var got config
+			if err := c.Struct("config", &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+type configWithUnmarshaler struct {
+	Name   string
+	Age    int
+	Weight float64
+}
+
+func (c *configWithUnmarshaler) UnmarshalJSON(data []byte) error {
+	split := strings.Split(string(data), ",")
+	conf := configWithUnmarshaler{}
+	for _, d := range split {
+		parts := strings.Split(d, ":")
+		switch strings.Trim(parts[0], "\"") {
+		case "name":
+			conf.Name = strings.Trim(parts[1], "\"")
+		case "age":
+			age, err := strconv.Atoi(strings.Trim(parts[1], "\""))
+			if err != nil {
+				return err
+			}
+			conf.Age = age
+		case "weight":
+			weight, err := strconv.ParseFloat(strings.Trim(parts[1], "\""), 64)
+			if err != nil {
+				return err
+			}
+			conf.Weight = weight
+		}
+	}
+
+	*c = conf
+	return nil
+}
+
+func TestConfigStructCustomUnmarshaler(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		want configWithUnmarshaler
+	}{
+		"string int": {
+			in: "age:10",
+			want: configWithUnmarshaler{
+				Age: 10,
+			},
+		},
+		"string float": {
+			in: "weight:10.1",
+			want: configWithUnmarshaler{
+				Weight: 10.1,
+			},
+		},
+		"string name": {
+			in:   "name:alice",
+			want: configWithUnmarshaler{Name: "alice"},
+		},
+		"multi key": {
+			in: "name:alice,age:10,weight:10.1",
+			want: configWithUnmarshaler{
+				Name:   "alice",
+				Age:    10,
+				Weight: 10.1,
+			},
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("config", tc.in)
+			var got configWithUnmarshaler
+			if err := c.Struct("config", &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigStructWith(t *testing.T) {
+	type config struct {
+		Name string
+		Age  int
+	}
+	// decode is a trivial codec of "name;age" values.
+	decode := func(data []byte, out interface{}) error {
+		parts := strings.Split(string(data), ";")
+		if len(parts) != 2 {
+			return errors.New("invalid value")
+		}
+		age, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return err
+		}
+
+		conf := out.(*config)
+		conf.Name = parts[0]
+		conf.Age = age
+		return nil
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		var got config
+		if err := c.StructWith("config", &got, decode); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+
+	c.settings.Store("config", "alice;10")
+	var got config
+	if err := c.StructWith("config", &got, decode); err != nil {
+		t.Fatal(err)
+	}
+	want := config{Name: "alice", Age: 10}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+
+	c.settings.Store("config", "alice")
+	if err := c.StructWith("config", &got, decode); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestConfigStructYAML(t *testing.T) {
+	type config struct {
+		Name   string   `json:"name" yaml:"name"`
+		Age    int      `json:"age" yaml:"age"`
+		Labels []string `json:"labels" yaml:"labels"`
+	}
+
+	tests := map[string]struct {
+		in      interface{}
+		want    config
+		wantErr bool
+	}{
+		"yaml": {
+			in:   "name: alice\nage: 10\nlabels:\n  - a\n  - b\n",
+			want: config{Name: "alice", Age: 10, Labels: []string{"a", "b"}},
+		},
+		"json": {
+			in:   `{"name": "alice", "age": 10}`,
+			want: config{Name: "alice", Age: 10},
+		},
+		"malformed": {
+			in:      "name: [alice",
+			wantErr: true,
+		},
+		"int": {
+			in:      10,
+			wantErr: true,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithStructFormatDetection())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("config", tc.in)
+
+			var got config
+			err := c.StructYAML("config", &got)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %t got %v", tc.wantErr, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error(diff)
+			}
+
+			// The format is detected.
+			var detected config
+			if err = c.Struct("config", &detected); (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %t got %v", tc.wantErr, err)
+			}
+			if diff := cmp.Diff(tc.want, detected); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+
+	t.Run("detection disabled", func(t *testing.T) {
+		c, err := New("/configs/curiosity/", WithLogger(logger))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+
+		c.settings.Store("config", "name: alice\n")
+		var got config
+		if err := c.Struct("config", &got); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+}
+
+func TestConfigStructTOML(t *testing.T) {
+	type config struct {
+		Name   string   `toml:"name"`
+		Age    int      `toml:"age"`
+		Labels []string `toml:"labels"`
+		Owner  struct {
+			Email string `toml:"email"`
+		} `toml:"owner"`
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.settings.Store("config", "name = \"alice\"\nage = 10\nlabels = [\"a\", \"b\"]\n\n[owner]\nemail = \"alice@example.com\"\n")
+	var got config
+	if err := c.StructTOML("config", &got); err != nil {
+		t.Fatal(err)
+	}
+	want := config{Name: "alice", Age: 10, Labels: []string{"a", "b"}}
+	want.Owner.Email = "alice@example.com"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	c.settings.Store("config", "name = alice")
+	if err := c.StructTOML("config", &got); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestConfigStructMsgpack(t *testing.T) {
+	type config struct {
+		Name   string   `msgpack:"name" yaml:"name"`
+		Age    int      `msgpack:"age" yaml:"age"`
+		Labels []string `msgpack:"labels" yaml:"labels"`
+	}
+	want := config{Name: "alice", Age: 10, Labels: []string{"a", "b"}}
+	b, err := msgpack.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithStructFormatDetection())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.settings.Store("config", string(b))
+	var got config
+	if err := c.StructMsgpack("config", &got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	// The format is detected.
+	var detected config
+	if err := c.Struct("config", &detected); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, detected); diff != "" {
+		t.Error(diff)
+	}
+
+	c.settings.Store("config", string(b[:len(b)-2]))
+	if err := c.Struct("config", &detected); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestConfigStructAs(t *testing.T) {
+	type config struct {
+		Name string `json:"name" yaml:"name"`
+		Age  int    `json:"age" yaml:"age"`
+	}
+	// decodeKV is a trivial codec of "name;age" values.
+	decodeKV := func(data []byte, out interface{}) error {
+		parts := strings.Split(string(data), ";")
+		if len(parts) != 2 {
+			return errors.New("invalid value")
+		}
+		age, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return err
+		}
+
+		*out.(*config) = config{Name: parts[0], Age: age}
+		return nil
+	}
+	// decodeStrictJSON rejects the unknown fields.
+	decodeStrictJSON := func(data []byte, out interface{}) error {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		return dec.Decode(out)
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New(
+		"/configs/curiosity/",
+		WithLogger(logger),
+		WithDecoder("kv", decodeKV),
+		WithDecoder("json", decodeStrictJSON),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	want := config{Name: "alice", Age: 10}
+	tests := map[string]struct {
+		in     string
+		format string
+	}{
+		"custom": {
+			in:     "alice;10",
+			format: "kv",
+		},
+		"builtin": {
+			in:     "name: alice\nage: 10\n",
+			format: "yaml",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("config", tc.in)
+			var got config
+			if err := c.StructAs("config", tc.format, &got); err != nil {
+				t.Fatal(err)
+			}
+			if want != got {
+				t.Errorf("expected %v got %v", want, got)
+			}
+		})
+	}
+
+	t.Run("unknown format", func(t *testing.T) {
+		c.settings.Store("config", "alice;10")
+		var got config
+		if err := c.StructAs("config", "hcl", &got); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+
+	t.Run("replaced json", func(t *testing.T) {
+		c.settings.Store("config", `{"name": "alice", "age": 10, "weight": 10.1}`)
+		var got config
+		if err := c.Struct("config", &got); err == nil {
+			t.Errorf("expected unknown field error")
+		}
+	})
+}
+
+func TestConfigStructOrDefault(t *testing.T) {
+	type limits struct {
+		RPS   int
+		Burst int
+	}
+	def := limits{RPS: 100, Burst: 10}
+
+	tests := map[string]struct {
+		in   interface{}
+		def  interface{}
+		want limits
+	}{
+		"valid": {
+			in:   `{"RPS": 5, "Burst": 1}`,
+			def:  def,
+			want: limits{RPS: 5, Burst: 1},
+		},
+		"partially invalid": {
+			in:   `{"RPS": 5, "Burst": "one"}`,
+			def:  def,
+			want: def,
+		},
+		"malformed": {
+			in:   `{"RPS": 5`,
+			def:  &def,
+			want: def,
+		},
+		"int": {
+			in:   5,
+			def:  def,
+			want: def,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		var got limits
+		c.StructOrDefault("limits", &got, def)
+		if got != def {
+			t.Errorf("expected %v got %v", def, got)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("limits", tc.in)
+			var got limits
+			c.StructOrDefault("limits", &got, tc.def)
+			if tc.want != got {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("mismatched default", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected panic")
+			}
+		}()
+		var got limits
+		c.StructOrDefault("limits", &got, "default")
+	})
+}
+
+func TestConfigJSONMap(t *testing.T) {
+	tests := map[string]struct {
+		in      interface{}
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		"object": {
+			in: `{"name": "alice", "age": 10, "tags": ["a"], "meta": {"beta": true}}`,
+			want: map[string]interface{}{
+				"name": "alice",
+				"age":  float64(10),
+				"tags": []interface{}{"a"},
+				"meta": map[string]interface{}{"beta": true},
+			},
+		},
+		"empty object": {
+			in:   `{}`,
+			want: map[string]interface{}{},
+		},
+		"null": {
+			in:      `null`,
+			wantErr: true,
+		},
+		"array": {
+			in:      `["alice"]`,
+			wantErr: true,
+		},
+		"malformed": {
+			in:      `{"name":`,
+			wantErr: true,
+		},
+		"int": {
+			in:      10,
+			wantErr: true,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		if _, err := c.JSONMap("feature"); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("feature", tc.in)
+			got, err := c.JSONMap("feature")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %t got %v", tc.wantErr, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+func TestConfigDuration(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		want time.Duration
+	}{
+		"duration second": {
+			in:   "10s",
+			want: 10 * time.Second,
+		},
+		"duration minute": {
+			in:   "10m",
+			want: 10 * time.Minute,
+		},
+		"duration hour": {
+			in:   "10h",
+			want: 10 * time.Hour,
+		},
+		"no duration": {
+			in:   "10",
+			want: 5 * time.Second,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("duration", tc.in)
+			got := c.Duration("duration", 5*time.Second)
+			if tc.want != got {
+				t.Errorf("expected %s got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigCron(t *testing.T) {
+	const defaultSchedule = "0 * * * *"
+
+	tests := map[string]struct {
+		in      interface{}
+		seconds bool
+		want    string
+		wantErr bool
+	}{
+		"5 fields": {
+			in:   "*/5 * * * *",
+			want: "*/5 * * * *",
+		},
+		"6 fields": {
+			in:      "*/30 * * * * *",
+			want:    defaultSchedule,
+			wantErr: true,
+		},
+		"6 fields with seconds": {
+			in:      "*/30 * * * * *",
+			seconds: true,
+			want:    "*/30 * * * * *",
+		},
+		"5 fields with seconds": {
+			in:      "*/5 * * * *",
+			seconds: true,
+			want:    "*/5 * * * *",
+		},
+		"descriptor": {
+			in:   "@hourly",
+			want: "@hourly",
+		},
+		"invalid": {
+			in:      "every minute",
+			want:    defaultSchedule,
+			wantErr: true,
+		},
+		"int": {
+			in:      100,
+			want:    defaultSchedule,
+			wantErr: true,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := []Option{WithLogger(logger)}
+			if tc.seconds {
+				opts = append(opts, WithCronSeconds())
+			}
+			c, err := New("/configs/curiosity/", opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			c.settings.Store("schedule", tc.in)
+			got := c.Cron("schedule", defaultSchedule)
+			if tc.want != got {
+				t.Errorf("expected %q got %q", tc.want, got)
+			}
+
+			_, err = c.CronRequired("schedule")
+			if tc.wantErr && err == nil {
+				t.Errorf("expected error")
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConfigStringArray(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		del  string
+		want []string
+	}{
+		"string array": {
+			in:   "alice,bob",
+			del:  ",",
+			want: []string{"alice", "bob"},
+		},
+		"string array with different separator": {
+			in:   "alice|bob",
+			del:  "|",
+			want: []string{"alice", "bob"},
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("names", tc.in)
+			got := c.StringArray("names", tc.del)
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigStringSet(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		want map[string]struct{}
+	}{
+		"set": {
+			in:   "alice,bob",
+			want: map[string]struct{}{"alice": {}, "bob": {}},
+		},
+		"whitespace and duplicates": {
+			in:   " alice , bob,alice,, ",
+			want: map[string]struct{}{"alice": {}, "bob": {}},
+		},
+		"empty": {
+			in:   "",
+			want: map[string]struct{}{},
+		},
+		"int": {
+			in: 10,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("allowlist", tc.in)
+			got := c.StringSet("allowlist", ",")
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigSortedArrays(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+
+	t.Run("disabled", func(t *testing.T) {
+		c, err := New("/configs/curiosity/", WithLogger(logger))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+
+		c.settings.Store("names", "c,a,b")
+		want := []string{"c", "a", "b"}
+		if got := c.StringArray("names", ","); !reflect.DeepEqual(want, got) {
+			t.Errorf("expected %v got %v", want, got)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		c, err := New("/configs/curiosity/", WithLogger(logger), WithSortedArrays())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+
+		c.settings.Store("names", "c,a,b")
+		wantNames := []string{"a", "b", "c"}
+		if got := c.StringArray("names", ","); !reflect.DeepEqual(wantNames, got) {
+			t.Errorf("expected %v got %v", wantNames, got)
+		}
+
+		c.settings.Store("ints", "3,1,2")
+		wantInts := []int{1, 2, 3}
+		if got := c.IntegerArray("ints", ","); !reflect.DeepEqual(wantInts, got) {
+			t.Errorf("expected %v got %v", wantInts, got)
+		}
+
+		c.settings.Store("ints", "3,-1,2")
+		wantInt64s := []int64{-1, 2, 3}
+		if got := c.Int64Array("ints", ","); !reflect.DeepEqual(wantInt64s, got) {
+			t.Errorf("expected %v got %v", wantInt64s, got)
+		}
+
+		c.settings.Store("uints", "3,1,2")
+		wantUints := []uint{1, 2, 3}
+		if got := c.UintArray("uints", ","); !reflect.DeepEqual(wantUints, got) {
+			t.Errorf("expected %v got %v", wantUints, got)
+		}
+
+		c.settings.Store("floats", "0.3,0.1,0.2")
+		wantFloats := []float64{0.1, 0.2, 0.3}
+		if got := c.FloatArray("floats", ","); !reflect.DeepEqual(wantFloats, got) {
+			t.Errorf("expected %v got %v", wantFloats, got)
+		}
+	})
+}
+
+func TestConfigIntegerArray(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		del  string
+		want []int
+	}{
+		"string array": {
+			in:   "10,20",
+			del:  ",",
+			want: []int{10, 20},
+		},
+		"string array with different separator": {
+			in:   "10|20",
+			del:  "|",
+			want: []int{10, 20},
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("numbers", tc.in)
+			got := c.IntegerArray("numbers", tc.del)
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigInt64Array(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		want []int64
+	}{
+		"int64 array": {
+			in:   "-10,9223372036854775807",
+			want: []int64{-10, math.MaxInt64},
+		},
+		"invalid element": {
+			in:   "10,alice",
+			want: []int64{10, 0},
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("shards", tc.in)
+			got := c.Int64Array("shards", ",")
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigUintArray(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		want []uint
+	}{
+		"uint array": {
+			in:   "1024,4096",
+			want: []uint{1024, 4096},
+		},
+		"negative element": {
+			in:   "1024,-1",
+			want: []uint{1024, 0},
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("limits", tc.in)
+			got := c.UintArray("limits", ",")
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigFloatArray(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		del  string
+		want []float64
+	}{
+		"string array": {
+			in:   "10.1,20.2",
+			del:  ",",
+			want: []float64{10.1, 20.2},
+		},
+		"string array with different separator": {
+			in:   "10.1|20.2",
+			del:  "|",
+			want: []float64{10.1, 20.2},
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("numbers", tc.in)
+			got := c.FloatArray("numbers", tc.del)
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigDateArray(t *testing.T) {
+	tests := map[string]struct {
+		in     interface{}
+		del    string
+		format string
+		want   []time.Time
+	}{
+		"string array": {
+			in:     "2020-01-01,2020-02-02",
+			del:    ",",
+			format: "2006-01-02",
+			want:   []time.Time{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 2, 2, 0, 0, 0, 0, time.UTC)},
+		},
+		"string array with different separator": {
+			in:     "2020-01-01|2020-02-02",
+			del:    "|",
+			format: "2006-01-02",
+			want:   []time.Time{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 2, 2, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("dates", tc.in)
+			got := c.DateArray("dates", tc.format, tc.del)
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigConfigSettings(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		want map[string]string
+	}{
+		"string int": {
+			in:   "10",
+			want: map[string]string{"name": "10"},
+		},
+		"string float": {
+			in:   "10.1",
+			want: map[string]string{"name": "10.1"},
+		},
+		"string name": {
+			in:   "alice",
+			want: map[string]string{"name": "alice"},
+		},
+		"bytes": {
+			in:   []byte("alice"),
+			want: map[string]string{"name": ""},
+		},
+		"nil": {
+			in:   nil,
+			want: map[string]string{"name": ""},
+		},
+		"int": {
+			in:   100,
+			want: map[string]string{"name": ""},
+		},
+		"float": {
+			in:   0.001,
+			want: map[string]string{"name": ""},
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("no keys", func(t *testing.T) {
+		got := c.Settings()
+		if got != nil {
+			t.Errorf("expected nil got %v", got)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("name", tc.in)
+			got := c.Settings()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestConfigKeys(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if c.Len() != 0 || c.Keys() != nil || c.Has("velocity") {
+		t.Fatal("expected no settings")
+	}
+
+	c.settings.Store("velocity", "5")
+	c.settings.Store("name", "curiosity")
+	if got := c.Len(); got != 2 {
+		t.Errorf("expected 2 settings got %d", got)
+	}
+	if diff := cmp.Diff([]string{"name", "velocity"}, c.Keys()); diff != "" {
+		t.Error(diff)
+	}
+	if !c.Has("velocity") || c.Has("mission") {
+		t.Error("expected only velocity to be found")
+	}
+}
+
+func TestConfigKeysOverridden(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.settings.Store("velocity", "5")
+
+	fs := flag.NewFlagSet("curiosity", flag.ContinueOnError)
+	velocity := fs.String("velocity", "1", "")
+	name := fs.String("name", "", "")
+	mission := fs.String("mission", "", "")
+	if err = fs.Parse([]string{"-velocity=7", "-name=curiosity"}); err != nil {
+		t.Fatal(err)
+	}
+	c.BindFlagSet(fs, map[string]*string{
+		"velocity": velocity,
+		"name":     name,
+		"mission":  mission,
+	})
+
+	if got := c.Len(); got != 2 {
+		t.Errorf("expected 2 settings got %d", got)
+	}
+	if diff := cmp.Diff([]string{"name", "velocity"}, c.Keys()); diff != "" {
+		t.Error(diff)
+	}
+	for _, k := range c.Keys() {
+		if !c.Has(k) {
+			t.Errorf("expected %s to be found", k)
+		}
+	}
+	if c.Has("mission") {
+		t.Error("expected mission not to be found")
+	}
+}
+
+func TestConfigGrouped(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("no keys", func(t *testing.T) {
+		if got := c.Grouped("."); got != nil {
+			t.Errorf("expected nil got %v", got)
+		}
+	})
+
+	c.settings.Store("camera.fps", "30")
+	c.settings.Store("camera.res", "1080p")
+	c.settings.Store("velocity", "10")
+
+	got := c.Grouped(".")
+	want := map[string]map[string]string{
+		"camera": {
+			"fps": "30",
+			"res": "1080p",
+		},
+		"": {
+			"velocity": "10",
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestConfigDurationArray(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		want []time.Duration
+	}{
+		"durations": {
+			in:   "1s,5s,30s",
+			want: []time.Duration{time.Second, 5 * time.Second, 30 * time.Second},
+		},
+		"invalid duration": {
+			in:   "100ms,5",
+			want: []time.Duration{100 * time.Millisecond, 0},
+		},
+		"int": {
+			in: 10,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("backoff", tc.in)
+			got := c.DurationArray("backoff", ",")
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigBooleanArray(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		del  string
+		want []bool
+	}{
+		"string array": {
+			in:  "true,false",
+			del: ",",
+			want: []bool{
+				true,
+				false,
+			},
+		},
+		"string array with different separator": {
+			in:  "true|false",
+			del: "|",
+			want: []bool{
+				true,
+				false,
+			},
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("bools", tc.in)
+			got := c.BooleanArray("bools", tc.del)
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigEndpoints(t *testing.T) {
+	tests := map[string]struct {
+		in      interface{}
+		want    []string
+		wantErr bool
+	}{
+		"valid": {
+			in:   "host1:9000,host2:9000,[::1]:9000",
+			want: []string{"host1:9000", "host2:9000", "[::1]:9000"},
+		},
+		"missing port": {
+			in:      "host1:9000,host2",
+			want:    []string{"host1:9000"},
+			wantErr: true,
+		},
+		"empty": {
+			in:      "",
+			wantErr: true,
+		},
+		"int": {
+			in:      100,
+			wantErr: true,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("endpoints", tc.in)
+			got := c.Endpoints("endpoints", ",")
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+
+			got, err := c.EndpointsRequired("endpoints", ",")
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigHostPort(t *testing.T) {
+	const defaultUpstream = "localhost:8080"
+
+	tests := map[string]struct {
+		in   interface{}
+		want string
+	}{
+		"host port": {
+			in:   "api.example.com:443",
+			want: "api.example.com:443",
+		},
+		"ipv6": {
+			in:   "[::1]:9000",
+			want: "[::1]:9000",
+		},
+		"empty host": {
+			in:   ":9000",
+			want: ":9000",
+		},
+		"missing port": {
+			in:   "api.example.com",
+			want: defaultUpstream,
+		},
+		"unbracketed ipv6": {
+			in:   "::1:9000",
+			want: defaultUpstream,
+		},
+		"int": {
+			in:   9000,
+			want: defaultUpstream,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("upstream", tc.in)
+			if got := c.HostPort("upstream", defaultUpstream); tc.want != got {
+				t.Errorf("expected %s got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigURL(t *testing.T) {
+	defaultUpstream := &url.URL{Scheme: "http", Host: "localhost:8080"}
+
+	tests := map[string]struct {
+		in           interface{}
+		absoluteURLs bool
+		want         string
+		wantErr      bool
+	}{
+		"absolute": {
+			in:   "https://api.example.com/v1?timeout=5s",
+			want: "https://api.example.com/v1?timeout=5s",
+		},
+		"relative": {
+			in:   "/v1",
+			want: "/v1",
+		},
+		"relative required absolute": {
+			in:           "/v1",
+			absoluteURLs: true,
+			want:         defaultUpstream.String(),
+			wantErr:      true,
+		},
+		"invalid": {
+			in:      "http://[::1",
+			want:    defaultUpstream.String(),
+			wantErr: true,
+		},
+		"int": {
+			in:      100,
+			want:    defaultUpstream.String(),
+			wantErr: true,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.absoluteURLs = tc.absoluteURLs
+			c.settings.Store("upstream", tc.in)
+			if got := c.URL("upstream", defaultUpstream).String(); tc.want != got {
+				t.Errorf("expected %s got %s", tc.want, got)
+			}
+
+			_, err := c.URLRequired("upstream")
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error %t got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConfigIP(t *testing.T) {
+	defaultBindAddr := net.IPv4zero
+
+	tests := map[string]struct {
+		in   interface{}
+		want net.IP
+	}{
+		"ipv4": {
+			in:   "192.168.1.10",
+			want: net.IPv4(192, 168, 1, 10),
+		},
+		"ipv6": {
+			in:   "::1",
+			want: net.IPv6loopback,
+		},
+		"host": {
+			in:   "localhost",
+			want: defaultBindAddr,
+		},
+		"with port": {
+			in:   "127.0.0.1:8080",
+			want: defaultBindAddr,
+		},
+		"bytes": {
+			in:   []byte{127, 0, 0, 1},
+			want: defaultBindAddr,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		if got := c.IP("bind_addr", defaultBindAddr); !defaultBindAddr.Equal(got) {
+			t.Errorf("expected %s got %s", defaultBindAddr, got)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("bind_addr", tc.in)
+			if got := c.IP("bind_addr", defaultBindAddr); !tc.want.Equal(got) {
+				t.Errorf("expected %s got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigCIDR(t *testing.T) {
+	_, defaultAllowlist, _ := net.ParseCIDR("127.0.0.0/8")
+
+	tests := map[string]struct {
+		in   interface{}
+		want string
+	}{
+		"ipv4": {
+			in:   "10.1.2.3/8",
+			want: "10.0.0.0/8",
+		},
+		"ipv6": {
+			in:   "2001:db8::/32",
+			want: "2001:db8::/32",
+		},
+		"ip": {
+			in:   "10.1.2.3",
+			want: defaultAllowlist.String(),
+		},
+		"int": {
+			in:   100,
+			want: defaultAllowlist.String(),
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("allowlist", tc.in)
+			if got := c.CIDR("allowlist", defaultAllowlist).String(); tc.want != got {
+				t.Errorf("expected %s got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigCIDRArray(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		want []string
+	}{
+		"valid": {
+			in:   "10.0.0.0/8,192.168.0.0/16,2001:db8::/32",
+			want: []string{"10.0.0.0/8", "192.168.0.0/16", "2001:db8::/32"},
+		},
+		"invalid network": {
+			in:   "10.0.0.0/8,192.168.0.1",
+			want: []string{"10.0.0.0/8"},
+		},
+		"empty": {
+			in: "",
+		},
+		"int": {
+			in: 100,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("allowlist", tc.in)
+			var got []string
+			for _, n := range c.CIDRArray("allowlist", ",") {
+				got = append(got, n.String())
+			}
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigArrayRequired(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	tests := map[string]struct {
+		in      interface{}
+		get     func() (interface{}, error)
+		want    interface{}
+		wantErr string
+	}{
+		"strings": {
+			in:   "alice,bob",
+			get:  func() (interface{}, error) { return c.StringArrayRequired("array", ",") },
+			want: []string{"alice", "bob"},
+		},
+		"strings invalid value": {
+			in:      10,
+			get:     func() (interface{}, error) { return c.StringArrayRequired("array", ",") },
+			wantErr: "dynconf invalid string value: array",
+		},
+		"integers": {
+			in:   "10,20",
+			get:  func() (interface{}, error) { return c.IntegerArrayRequired("array", ",") },
+			want: []int{10, 20},
+		},
+		"integers invalid element": {
+			in:      "10,alice,20",
+			get:     func() (interface{}, error) { return c.IntegerArrayRequired("array", ",") },
+			wantErr: `dynconf invalid integer setting array at index 1: "alice"`,
+		},
+		"floats": {
+			in:   "0.1,2",
+			get:  func() (interface{}, error) { return c.FloatArrayRequired("array", ",") },
+			want: []float64{0.1, 2},
+		},
+		"floats invalid element": {
+			in:      "0.1,",
+			get:     func() (interface{}, error) { return c.FloatArrayRequired("array", ",") },
+			wantErr: `dynconf invalid float setting array at index 1: ""`,
+		},
+		"booleans": {
+			in:   "true,0",
+			get:  func() (interface{}, error) { return c.BooleanArrayRequired("array", ",") },
+			want: []bool{true, false},
+		},
+		"booleans invalid element": {
+			in:      "yes,true",
+			get:     func() (interface{}, error) { return c.BooleanArrayRequired("array", ",") },
+			wantErr: `dynconf invalid boolean setting array at index 0: "yes"`,
+		},
+		"dates": {
+			in:   "2021-03-10",
+			get:  func() (interface{}, error) { return c.DateArrayRequired("array", "2006-01-02", ",") },
+			want: []time.Time{time.Date(2021, time.March, 10, 0, 0, 0, 0, time.UTC)},
+		},
+		"dates invalid element": {
+			in:      "2021-03-10,2021-13-01",
+			get:     func() (interface{}, error) { return c.DateArrayRequired("array", "2006-01-02", ",") },
+			wantErr: `dynconf invalid date setting array at index 1: "2021-13-01"`,
+		},
+	}
+
+	t.Run("no key", func(t *testing.T) {
+		if _, err := c.IntegerArrayRequired("array", ","); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("array", tc.in)
+			got, err := tc.get()
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("expected error %q got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestConfigArrayLen(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		del  string
+		want int
+	}{
+		"three elements": {
+			in:   "a,b,c",
+			del:  ",",
+			want: 3,
+		},
+		"single element": {
+			in:   "a",
+			del:  ",",
+			want: 1,
+		},
+		"different separator": {
+			in:   "a|b",
+			del:  "|",
+			want: 2,
+		},
+		"empty": {
+			in:   "",
+			del:  ",",
+			want: 0,
+		},
+		"empty separator": {
+			in:   "añb",
+			del:  "",
+			want: 3,
+		},
+		"int": {
+			in:   100,
+			del:  ",",
+			want: 0,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		if got := c.ArrayLen("shards", ","); got != 0 {
+			t.Errorf("expected 0 got %d", got)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("shards", tc.in)
+			got := c.ArrayLen("shards", tc.del)
+			if tc.want != got {
+				t.Errorf("expected %d got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	etcd, err := clientv3.New(clientv3.Config{
+		Endpoints: []string{"127.0.0.1:2379"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithEtcdClient(etcd), WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if r, err := etcd.Put(ctx, "/configs/curiosity/velocity", "5"); err != nil {
+		t.Fatalf("failed to put velocity=5 setting: %v %v", err, r)
+	}
+	// Wait for the watcher to see the changes in etcd.
+	time.Sleep(time.Second)
+
+	got := c.Integer("velocity", 10)
+	want := 5
+	if want != got {
+		t.Errorf("expected velocity %d got %d", want, got)
+	}
+}
+
+func TestOnUpdate(t *testing.T) {
+	etcd, err := clientv3.New(clientv3.Config{
+		Endpoints: []string{"127.0.0.1:2379"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := "0"
+	onUpdate := func(s map[string]string) {
+		t.Logf("updated: %v", s)
+		received = s["velocity"]
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithEtcdClient(etcd), WithLogger(logger), WithOnUpdate(onUpdate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if r, err := etcd.Put(ctx, "/configs/curiosity/velocity", "5"); err != nil {
+		t.Fatalf("failed to put velocity=5 setting: %v %v", err, r)
+	}
+	// Wait for the watcher to see the changes in etcd.
+	time.Sleep(time.Second)
+
+	got := c.Integer("velocity", 10)
+	want := 5
+	if want != got {
+		t.Errorf("expected velocity %d got %d", want, got)
+	}
+
+	if received != "5" {
+		t.Errorf("expected received %s got %s", "5", received)
+	}
+}
+
+func TestReady(t *testing.T) {
+	etcd, err := clientv3.New(clientv3.Config{
+		Endpoints: []string{"127.0.0.1:2379"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithEtcdClient(etcd), WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancelCtx := context.WithTimeout(context.Background(), time.Second)
+	defer cancelCtx()
+	if r, err := etcd.Put(ctx, "/configs/curiosity/velocity", "5"); err != nil {
+		t.Errorf("failed to put velocity=5 setting: %v %v", err, r)
+		return
+	}
+	// Wait for the watcher to see the changes in etcd.
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err = c.Ready(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := c.Integer("velocity", 10)
+	want := 5
+	if want != got {
+		t.Errorf("expected velocity %d got %d", want, got)
+	}
+}
+
+func TestLag(t *testing.T) {
+	etcd := newEtcdClient(t)
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithEtcdClient(etcd), WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if r, err := etcd.Put(ctx, "/configs/curiosity/velocity", "5"); err != nil {
+		t.Fatalf("failed to put velocity=5 setting: %v %v", err, r)
+	}
+	// Wait for the watcher to see the changes in etcd.
+	time.Sleep(time.Second)
+
+	lag, err := c.Lag(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lag > 100*time.Millisecond {
+		t.Errorf("expected lag near zero got %s", lag)
+	}
+}
+
+func TestEnvExpansion(t *testing.T) {
+	t.Setenv("DYNCONF_FOO", "foo")
+
+	tests := map[string]struct {
+		in   string
+		want string
+	}{
+		"braces": {
+			in:   "${DYNCONF_FOO}/x",
+			want: "foo/x",
 		},
-		"string array with different separator": {
-			in:   "10|20",
-			del:  "|",
-			want: []int{10, 20},
+		"no braces": {
+			in:   "$DYNCONF_FOO-worker",
+			want: "foo-worker",
+		},
+		"unknown": {
+			in:   "${DYNCONF_UNKNOWN}/x",
+			want: "/x",
+		},
+		"no vars": {
+			in:   "alice",
+			want: "alice",
 		},
 	}
 
 	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
-	c, err := New("/configs/curiosity/", WithLogger(logger))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithEnvExpansion())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -731,33 +3422,15 @@ func TestConfigIntegerArray(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			c.settings.Store("numbers", tc.in)
-			got := c.IntegerArray("numbers", tc.del)
-			if !reflect.DeepEqual(tc.want, got) {
-				t.Errorf("expected %v got %v", tc.want, got)
+			got := c.expand("name", tc.in)
+			if tc.want != got {
+				t.Errorf("expected %q got %q", tc.want, got)
 			}
 		})
 	}
 }
 
-func TestConfigFloatArray(t *testing.T) {
-	tests := map[string]struct {
-		in   interface{}
-		del  string
-		want []float64
-	}{
-		"string array": {
-			in:   "10.1,20.2",
-			del:  ",",
-			want: []float64{10.1, 20.2},
-		},
-		"string array with different separator": {
-			in:   "10.1|20.2",
-			del:  "|",
-			want: []float64{10.1, 20.2},
-		},
-	}
-
+func TestAwaitFunc(t *testing.T) {
 	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
 	c, err := New("/configs/curiosity/", WithLogger(logger))
 	if err != nil {
@@ -769,38 +3442,35 @@ func TestConfigFloatArray(t *testing.T) {
 		}
 	})
 
-	for name, tc := range tests {
-		t.Run(name, func(t *testing.T) {
-			c.settings.Store("numbers", tc.in)
-			got := c.FloatArray("numbers", tc.del)
-			if !reflect.DeepEqual(tc.want, got) {
-				t.Errorf("expected %v got %v", tc.want, got)
-			}
-		})
+	pred := func(s map[string]string) bool {
+		_, hasVelocity := s["velocity"]
+		_, hasCamera := s["is_camera_enabled"]
+		return hasVelocity && hasCamera
 	}
-}
 
-func TestConfigDateArray(t *testing.T) {
-	tests := map[string]struct {
-		in     interface{}
-		del    string
-		format string
-		want   []time.Time
-	}{
-		"string array": {
-			in:     "2020-01-01,2020-02-02",
-			del:    ",",
-			format: "2006-01-02",
-			want:   []time.Time{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 2, 2, 0, 0, 0, 0, time.UTC)},
-		},
-		"string array with different separator": {
-			in:     "2020-01-01|2020-02-02",
-			del:    "|",
-			format: "2006-01-02",
-			want:   []time.Time{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 2, 2, 0, 0, 0, 0, time.UTC)},
-		},
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		done <- c.AwaitFunc(ctx, pred)
+	}()
+
+	c.settings.Store("velocity", "5")
+	c.notify()
+	select {
+	case err = <-done:
+		t.Fatalf("expected to wait for is_camera_enabled got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	c.settings.Store("is_camera_enabled", "true")
+	c.notify()
+	if err = <-done; err != nil {
+		t.Fatal(err)
 	}
+}
 
+func TestAwaitFuncTimeout(t *testing.T) {
 	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
 	c, err := New("/configs/curiosity/", WithLogger(logger))
 	if err != nil {
@@ -812,102 +3482,101 @@ func TestConfigDateArray(t *testing.T) {
 		}
 	})
 
-	for name, tc := range tests {
-		t.Run(name, func(t *testing.T) {
-			c.settings.Store("dates", tc.in)
-			got := c.DateArray("dates", tc.format, tc.del)
-			if !reflect.DeepEqual(tc.want, got) {
-				t.Errorf("expected %v got %v", tc.want, got)
-			}
-		})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = c.AwaitFunc(ctx, func(s map[string]string) bool {
+		return s["velocity"] == "5"
+	})
+	if err == nil {
+		t.Errorf("expected error")
 	}
 }
 
-func TestConfigConfigSettings(t *testing.T) {
+func TestConfigWeightedFloats(t *testing.T) {
 	tests := map[string]struct {
-		in   interface{}
-		want map[string]string
+		in        interface{}
+		normalize bool
+		want      map[string]float64
 	}{
-		"string int": {
-			in:   "10",
-			want: map[string]string{"name": "10"},
-		},
-		"string float": {
-			in:   "10.1",
-			want: map[string]string{"name": "10.1"},
-		},
-		"string name": {
-			in:   "alice",
-			want: map[string]string{"name": "alice"},
+		"weights": {
+			in:   "a:0.5,b:0.3,c:0.2",
+			want: map[string]float64{"a": 0.5, "b": 0.3, "c": 0.2},
 		},
-		"bytes": {
-			in:   []byte("alice"),
-			want: map[string]string{"name": ""},
+		"malformed pair": {
+			in:   "a:0.5,b,c:x",
+			want: map[string]float64{"a": 0.5},
 		},
-		"nil": {
-			in:   nil,
-			want: map[string]string{"name": ""},
+		"normalized": {
+			in:        "a:2,b:1,c:1",
+			normalize: true,
+			want:      map[string]float64{"a": 0.5, "b": 0.25, "c": 0.25},
 		},
 		"int": {
-			in:   100,
-			want: map[string]string{"name": ""},
-		},
-		"float": {
-			in:   0.001,
-			want: map[string]string{"name": ""},
+			in: 100,
 		},
 	}
 
 	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
-	c, err := New("/configs/curiosity/", WithLogger(logger))
-	if err != nil {
-		t.Fatal(err)
-	}
-	t.Cleanup(func() {
-		if err := c.Close(); err != nil {
-			t.Fatal(err)
-		}
-	})
-
-	t.Run("no keys", func(t *testing.T) {
-		got := c.Settings()
-		if got != nil {
-			t.Errorf("expected nil got %v", got)
-		}
-	})
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			c.settings.Store("name", tc.in)
-			got := c.Settings()
+			opts := []Option{WithLogger(logger)}
+			if tc.normalize {
+				opts = append(opts, WithNormalizeWeights())
+			}
+			c, err := New("/configs/curiosity/", opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			c.settings.Store("weights", tc.in)
+			got := c.WeightedFloats("weights", ",", ":")
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
 		})
 	}
+
+	t.Run("no key", func(t *testing.T) {
+		c, err := New("/configs/curiosity/", WithLogger(logger))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+
+		if got := c.WeightedFloats("weights", ",", ":"); got != nil {
+			t.Errorf("expected nil got %v", got)
+		}
+	})
 }
 
-func TestConfigBooleanArray(t *testing.T) {
+func TestConfigMap(t *testing.T) {
 	tests := map[string]struct {
-		in   interface{}
-		del  string
-		want []bool
+		in      interface{}
+		want    map[string]string
+		wantErr bool
 	}{
-		"string array": {
-			in:  "true,false",
-			del: ",",
-			want: []bool{
-				true,
-				false,
-			},
+		"pairs": {
+			in:   "us=10,eu=20",
+			want: map[string]string{"us": "10", "eu": "20"},
 		},
-		"string array with different separator": {
-			in:  "true|false",
-			del: "|",
-			want: []bool{
-				true,
-				false,
-			},
+		"value with delimiter": {
+			in:   "query=a=b,empty=",
+			want: map[string]string{"query": "a=b", "empty": ""},
+		},
+		"empty": {
+			in:   "",
+			want: map[string]string{},
+		},
+		"malformed pair": {
+			in:      "us=10,eu",
+			want:    map[string]string{"us": "10"},
+			wantErr: true,
+		},
+		"int": {
+			in:      10,
+			wantErr: true,
 		},
 	}
 
@@ -922,24 +3591,38 @@ func TestConfigBooleanArray(t *testing.T) {
 		}
 	})
 
+	t.Run("no key", func(t *testing.T) {
+		if got := c.Map("regions", ",", "="); got != nil {
+			t.Errorf("expected nil got %v", got)
+		}
+		if _, err := c.MapRequired("regions", ",", "="); err == nil {
+			t.Error("expected error")
+		}
+	})
+
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			c.settings.Store("bools", tc.in)
-			got := c.BooleanArray("bools", tc.del)
-			if !reflect.DeepEqual(tc.want, got) {
-				t.Errorf("expected %v got %v", tc.want, got)
+			c.settings.Store("regions", tc.in)
+			if diff := cmp.Diff(tc.want, c.Map("regions", ",", "=")); diff != "" {
+				t.Error(diff)
+			}
+
+			got, err := c.MapRequired("regions", ",", "=")
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error(diff)
 			}
 		})
 	}
 }
 
-func TestNew(t *testing.T) {
-	etcd, err := clientv3.New(clientv3.Config{
-		Endpoints: []string{"127.0.0.1:2379"},
-	})
-	if err != nil {
-		t.Fatal(err)
-	}
+func TestPing(t *testing.T) {
+	etcd := newEtcdClient(t)
 
 	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
 	c, err := New("/configs/curiosity/", WithEtcdClient(etcd), WithLogger(logger))
@@ -954,35 +3637,21 @@ func TestNew(t *testing.T) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
-	if r, err := etcd.Put(ctx, "/configs/curiosity/velocity", "5"); err != nil {
-		t.Fatalf("failed to put velocity=5 setting: %v %v", err, r)
-	}
-	// Wait for the watcher to see the changes in etcd.
-	time.Sleep(time.Second)
-
-	got := c.Integer("velocity", 10)
-	want := 5
-	if want != got {
-		t.Errorf("expected velocity %d got %d", want, got)
+	if err = c.Ping(ctx); err != nil {
+		t.Fatal(err)
 	}
 }
 
-func TestOnUpdate(t *testing.T) {
+func TestPingUnreachable(t *testing.T) {
 	etcd, err := clientv3.New(clientv3.Config{
-		Endpoints: []string{"127.0.0.1:2379"},
+		Endpoints: []string{"127.0.0.1:1"},
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	received := "0"
-	onUpdate := func(s map[string]string) {
-		t.Logf("updated: %v", s)
-		received = s["velocity"]
-	}
-
 	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
-	c, err := New("/configs/curiosity/", WithEtcdClient(etcd), WithLogger(logger), WithOnUpdate(onUpdate))
+	c, err := New("/configs/curiosity/", WithEtcdClient(etcd), WithLogger(logger))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -992,35 +3661,72 @@ func TestOnUpdate(t *testing.T) {
 		}
 	})
 
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err = c.Ping(ctx); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestRequireKeys(t *testing.T) {
+	etcd := newEtcdClient(t)
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 	if r, err := etcd.Put(ctx, "/configs/curiosity/velocity", "5"); err != nil {
 		t.Fatalf("failed to put velocity=5 setting: %v %v", err, r)
 	}
-	// Wait for the watcher to see the changes in etcd.
-	time.Sleep(time.Second)
-
-	got := c.Integer("velocity", 10)
-	want := 5
-	if want != got {
-		t.Errorf("expected velocity %d got %d", want, got)
+	if r, err := etcd.Delete(ctx, "/configs/curiosity/is_camera_enabled"); err != nil {
+		t.Fatalf("failed to delete is_camera_enabled setting: %v %v", err, r)
 	}
 
-	if received != "5" {
-		t.Errorf("expected received %s got %s", "5", received)
-	}
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+
+	t.Run("present", func(t *testing.T) {
+		c, err := New("/configs/curiosity/", WithLogger(logger), WithRequireKeys("velocity"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		_, err := New("/configs/curiosity/", WithLogger(logger), WithRequireKeys("velocity", "is_camera_enabled"))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if !strings.Contains(err.Error(), "is_camera_enabled") {
+			t.Errorf("expected is_camera_enabled to be reported: %v", err)
+		}
+	})
 }
 
-func TestReady(t *testing.T) {
+func TestRequireKeysNotReady(t *testing.T) {
 	etcd, err := clientv3.New(clientv3.Config{
-		Endpoints: []string{"127.0.0.1:2379"},
+		Endpoints: []string{"127.0.0.1:1"},
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
-	c, err := New("/configs/curiosity/", WithEtcdClient(etcd), WithLogger(logger))
+	_, err = New(
+		"/configs/curiosity/",
+		WithEtcdClient(etcd),
+		WithLogger(logger),
+		WithRequireKeys("velocity"),
+		WithStartupTimeout(100*time.Millisecond),
+	)
+	if err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestAccessStats(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1030,24 +3736,19 @@ func TestReady(t *testing.T) {
 		}
 	})
 
-	ctx, cancelCtx := context.WithTimeout(context.Background(), time.Second)
-	defer cancelCtx()
-	if r, err := etcd.Put(ctx, "/configs/curiosity/velocity", "5"); err != nil {
-		t.Errorf("failed to put velocity=5 setting: %v %v", err, r)
-		return
+	c.settings.Store("velocity", "5")
+	for i := 0; i < 3; i++ {
+		c.Integer("velocity", 10)
 	}
-	// Wait for the watcher to see the changes in etcd.
+	c.String("name", "curiosity")
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-	err = c.Ready(ctx)
-	if err != nil {
-		t.Fatal(err)
+	want := map[string]uint64{"velocity": 3, "name": 1}
+	if diff := cmp.Diff(want, c.AccessStats()); diff != "" {
+		t.Fatal(diff)
 	}
 
-	got := c.Integer("velocity", 10)
-	want := 5
-	if want != got {
-		t.Errorf("expected velocity %d got %d", want, got)
+	c.ResetAccessStats()
+	if got := c.AccessStats(); len(got) != 0 {
+		t.Errorf("expected no stats got %v", got)
 	}
 }
@@ -0,0 +1,45 @@
+package dynconf
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// envSource is a Source which reads the settings from the environment variables with a prefix.
+type envSource struct {
+	prefix string
+}
+
+// NewEnvSource returns a Source where the settings are the environment variables with the prefix.
+// The setting name is the lowercase variable name without the prefix,
+// e.g., if the prefix is CURIOSITY_, then CURIOSITY_VELOCITY variable is velocity setting.
+// The environment is read once since it doesn't change, so it's mostly useful
+// as an overriding layer, see NewLayeredSource.
+func NewEnvSource(prefix string) Source {
+	return &envSource{prefix: prefix}
+}
+
+// Load reads the environment variables with the prefix.
+func (s *envSource) Load(ctx context.Context) (map[string]string, int64, error) {
+	settings := make(map[string]string)
+	for _, kv := range os.Environ() {
+		i := strings.IndexByte(kv, '=')
+		if i < 0 || !strings.HasPrefix(kv[:i], s.prefix) || i == len(s.prefix) {
+			continue
+		}
+		settings[strings.ToLower(kv[len(s.prefix):i])] = kv[i+1:]
+	}
+
+	return settings, 1, nil
+}
+
+// Watch returns a channel which is closed when ctx is canceled since the environment doesn't change.
+func (s *envSource) Watch(ctx context.Context) <-chan SourceUpdate {
+	return noUpdates(ctx)
+}
+
+// Close does nothing.
+func (s *envSource) Close() error {
+	return nil
+}
@@ -0,0 +1,129 @@
+package dynconf
+
+import (
+	"fmt"
+	"sync"
+)
+
+// eventsBufferSize is the capacity of the channel returned by Events.
+const eventsBufferSize = 64
+
+// EventType is the type of a setting's change.
+type EventType int
+
+const (
+	// EventPut means the setting was created or updated.
+	EventPut EventType = iota + 1
+	// EventDelete means the setting was deleted.
+	EventDelete
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventPut:
+		return "put"
+	case EventDelete:
+		return "delete"
+	default:
+		return fmt.Sprintf("EventType(%d)", int(t))
+	}
+}
+
+// Event describes a change of a setting along with the store's revision, e.g., for audit logs.
+type Event struct {
+	// Setting is the name of the changed setting.
+	Setting string
+	// Old is the previous value of the setting, empty if the setting didn't exist.
+	Old string
+	// New is the new value of the setting, empty if the setting was deleted.
+	New string
+	// Type tells whether the setting was put or deleted.
+	Type EventType
+	// Revision is the revision of the store when the setting was changed, e.g., etcd mod revision.
+	Revision int64
+}
+
+// Events returns a channel that receives every setting change in the order they happened,
+// and a function which stops the events and closes the channel.
+// The channel is closed when the Config is closed as well.
+// Note, the channel is buffered, but once the buffer is full
+// a slow reader holds back the settings updates until it reads the events or stops them.
+func (c *Config) Events() (<-chan Event, func()) {
+	var (
+		mu     sync.Mutex
+		closed bool
+	)
+	out := make(chan Event, eventsBufferSize)
+	// stop unblocks the sending when the events are stopped.
+	stop := make(chan struct{})
+	send := func(events []Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		for _, e := range events {
+			select {
+			case out <- e:
+			case <-stop:
+				return
+			case <-c.done:
+				return
+			}
+		}
+	}
+
+	c.mu.Lock()
+	id := c.nextListenerID
+	c.nextListenerID++
+	c.eventListeners[id] = send
+	c.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			close(stop)
+			c.mu.Lock()
+			delete(c.eventListeners, id)
+			c.mu.Unlock()
+
+			mu.Lock()
+			closed = true
+			close(out)
+			mu.Unlock()
+		})
+	}
+	go func() {
+		select {
+		case <-c.done:
+			cancel()
+		case <-stop:
+		}
+	}()
+
+	return out, cancel
+}
+
+// emit sends the changes with their revisions to the event listeners.
+func (c *Config) emit(changes []Change, revs []int64) {
+	c.mu.Lock()
+	ll := make([]func([]Event), 0, len(c.eventListeners))
+	for _, fn := range c.eventListeners {
+		ll = append(ll, fn)
+	}
+	c.mu.Unlock()
+	if len(ll) == 0 {
+		return
+	}
+
+	events := make([]Event, len(changes))
+	for i, ch := range changes {
+		events[i] = Event{Setting: ch.Setting, Old: ch.Old, New: ch.New, Type: EventPut, Revision: revs[i]}
+		if ch.Deleted {
+			events[i].Type = EventDelete
+		}
+	}
+	for _, fn := range ll {
+		fn(events)
+	}
+}
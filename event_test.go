@@ -0,0 +1,123 @@
+package dynconf
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/google/go-cmp/cmp"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestEvents(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, _ := c.Events()
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+		putEvent("/configs/curiosity/velocity", "5", 2),
+		putEvent("/configs/curiosity/name", "curiosity", 3),
+	}, 3))
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+		deleteEvent("/configs/curiosity/velocity", 4),
+	}, 4))
+	// The sources without revisions use the update's revision.
+	c.update(SourceUpdate{Events: []SourceEvent{{Setting: "mission", Value: "mars"}}, Revision: 5})
+
+	want := []Event{
+		{Setting: "velocity", New: "5", Type: EventPut, Revision: 2},
+		{Setting: "name", New: "curiosity", Type: EventPut, Revision: 3},
+		{Setting: "velocity", Old: "5", Type: EventDelete, Revision: 4},
+		{Setting: "mission", New: "mars", Type: EventPut, Revision: 5},
+	}
+	var got []Event
+	for range want {
+		select {
+		case e := <-events:
+			got = append(got, e)
+		case <-time.After(time.Second):
+			t.Fatalf("expected %d events got %v", len(want), got)
+		}
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+
+	if err = c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e, ok := <-events:
+		if ok {
+			t.Errorf("unexpected event %v", e)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected the channel to be closed")
+	}
+}
+
+func TestEventsStopped(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// The reader has stopped reading, so the updates block once the buffer is full.
+	events, cancel := c.Events()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 2*eventsBufferSize; i++ {
+			rev := int64(i + 2)
+			c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/velocity", strconv.Itoa(i), rev)}, rev))
+		}
+	}()
+	select {
+	case <-done:
+		t.Fatal("expected the updates to be held back")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the updates to go on once the events are stopped")
+	}
+	if got := c.Integer("velocity", 0); got != 2*eventsBufferSize-1 {
+		t.Errorf("expected velocity %d got %d", 2*eventsBufferSize-1, got)
+	}
+
+	n := 0
+	for range events {
+		n++
+	}
+	if n != eventsBufferSize {
+		t.Errorf("expected %d buffered events got %d", eventsBufferSize, n)
+	}
+}
+
+func TestEventTypeString(t *testing.T) {
+	tests := map[EventType]string{
+		EventPut:     "put",
+		EventDelete:  "delete",
+		EventType(0): "EventType(0)",
+	}
+	for in, want := range tests {
+		if got := in.String(); got != want {
+			t.Errorf("expected %q got %q", want, got)
+		}
+	}
+}
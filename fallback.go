@@ -0,0 +1,99 @@
+package dynconf
+
+import "time"
+
+// The Any getters try the given settings in order and read the first one which was found,
+// so a setting can be renamed while its legacy name is still honored, e.g.,
+//
+//	c.IntegerAny(10, "rover_velocity", "velocity")
+//
+// The value of the first setting found is used even if it's invalid, i.e., defaultValue is returned then.
+
+// StringAny returns the string value of the first setting found,
+// or defaultValue if none was found.
+func (c *Config) StringAny(defaultValue string, settings ...string) string {
+	setting, ok := c.first(settings)
+	if !ok {
+		return defaultValue
+	}
+	return c.String(setting, defaultValue)
+}
+
+// BooleanAny returns the boolean value of the first setting found,
+// or defaultValue if none was found or parsing failed.
+func (c *Config) BooleanAny(defaultValue bool, settings ...string) bool {
+	setting, ok := c.first(settings)
+	if !ok {
+		return defaultValue
+	}
+	return c.Boolean(setting, defaultValue)
+}
+
+// IntegerAny returns the integer value of the first setting found,
+// or defaultValue if none was found or parsing failed.
+func (c *Config) IntegerAny(defaultValue int, settings ...string) int {
+	setting, ok := c.first(settings)
+	if !ok {
+		return defaultValue
+	}
+	return c.Integer(setting, defaultValue)
+}
+
+// Int64Any returns the int64 value of the first setting found,
+// or defaultValue if none was found or parsing failed.
+func (c *Config) Int64Any(defaultValue int64, settings ...string) int64 {
+	setting, ok := c.first(settings)
+	if !ok {
+		return defaultValue
+	}
+	return c.Int64(setting, defaultValue)
+}
+
+// FloatAny returns the float value of the first setting found,
+// or defaultValue if none was found or parsing failed.
+func (c *Config) FloatAny(defaultValue float64, settings ...string) float64 {
+	setting, ok := c.first(settings)
+	if !ok {
+		return defaultValue
+	}
+	return c.Float(setting, defaultValue)
+}
+
+// DurationAny returns the duration value of the first setting found,
+// or defaultValue if none was found or parsing failed.
+func (c *Config) DurationAny(defaultValue time.Duration, settings ...string) time.Duration {
+	setting, ok := c.first(settings)
+	if !ok {
+		return defaultValue
+	}
+	return c.Duration(setting, defaultValue)
+}
+
+// first returns the first setting which was found.
+func (c *Config) first(settings []string) (string, bool) {
+	for _, s := range settings {
+		if c.present(s) {
+			return s, true
+		}
+	}
+
+	c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", settings, "err", "not found")
+	return "", false
+}
+
+// present reports whether the setting was found without counting it as read.
+func (c *Config) present(setting string) bool {
+	if v, ok := c.overrides.Load(setting); ok {
+		if p, _ := v.(*string); p != nil {
+			return true
+		}
+	}
+
+	if c.lru != nil {
+		_, ok := c.lookupLazy(setting)
+		return ok
+	}
+
+	_, ok := c.settings.Load(setting)
+	return ok
+}
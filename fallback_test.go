@@ -0,0 +1,44 @@
+package dynconf
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+func TestConfigAny(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.settings.Store("velocity", "5")
+	c.settings.Store("rover_name", "curiosity")
+	c.settings.Store("name", "opportunity")
+	c.settings.Store("timeout", "fast")
+
+	if got := c.StringAny("", "rover_name", "name"); got != "curiosity" {
+		t.Errorf("expected name %q got %q", "curiosity", got)
+	}
+	if got := c.IntegerAny(10, "rover_velocity", "velocity"); got != 5 {
+		t.Errorf("expected velocity 5 got %d", got)
+	}
+	if got := c.FloatAny(1.5, "rover_speed", "speed"); got != 1.5 {
+		t.Errorf("expected default speed got %f", got)
+	}
+	// The first setting found is used even if it's invalid.
+	if got := c.DurationAny(time.Second, "timeout", "velocity"); got != time.Second {
+		t.Errorf("expected default timeout got %s", got)
+	}
+	if got := c.AccessStats()["velocity"]; got != 1 {
+		t.Errorf("expected velocity to be read once got %d", got)
+	}
+}
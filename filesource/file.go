@@ -0,0 +1,156 @@
+// Package filesource provides a dynconf.Source backed by a local file,
+// so dynconf can be used in development and air-gapped environments with no datastore.
+package filesource
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pooyakn/dynconf"
+	"github.com/pooyakn/dynconf/internal/parse"
+)
+
+// Source is a dynconf.Source which keeps the settings in a JSON, YAML, or env file.
+// The format is chosen by the file extension: .json, .yaml or .yml, and .env.
+//
+// JSON and YAML files must contain an object whose keys are the settings.
+// The strings are used as is, the other scalars are formatted,
+// and the nested objects and arrays are encoded as JSON to be read with Config.Struct.
+// Env files contain KEY=VALUE lines, where the values can be quoted,
+// the blank lines and the lines starting with # are ignored.
+//
+// The file's directory is watched rather than the file itself,
+// so the file can be replaced atomically, e.g., by an editor or a Kubernetes volume.
+// When the file can't be read or parsed, the last known settings are kept.
+// The Source counts the updates to use them as revisions.
+type Source struct {
+	name string
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// settings are the last known settings used to detect the changes.
+	settings map[string]string
+	// rev is the number of updates.
+	rev int64
+}
+
+// New returns a Source which reads the settings from the named file.
+func New(name string) *Source {
+	return &Source{
+		name:     filepath.Clean(name),
+		settings: make(map[string]string),
+	}
+}
+
+// Load reads the settings from the file.
+func (s *Source) Load(ctx context.Context) (map[string]string, int64, error) {
+	settings, err := s.read()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = make(map[string]string, len(settings))
+	for k, v := range settings {
+		s.settings[k] = v
+	}
+	s.rev++
+
+	return settings, s.rev, nil
+}
+
+// Watch rereads the file whenever its directory changes and reports the settings' changes until ctx is canceled.
+func (s *Source) Watch(ctx context.Context) <-chan dynconf.SourceUpdate {
+	out := make(chan dynconf.SourceUpdate)
+	go func() {
+		defer close(out)
+		send := func(u dynconf.SourceUpdate) {
+			select {
+			case out <- u:
+			case <-ctx.Done():
+			}
+		}
+
+		w, err := fsnotify.NewWatcher()
+		if err != nil {
+			send(dynconf.SourceUpdate{Err: err})
+			return
+		}
+		defer w.Close()
+		if err = w.Add(filepath.Dir(s.name)); err != nil {
+			send(dynconf.SourceUpdate{Err: err})
+			return
+		}
+		// The file might have changed before the watch started.
+		send(s.refresh())
+
+		for {
+			select {
+			case <-w.Events:
+				if u := s.refresh(); u.Err != nil || len(u.Events) != 0 {
+					send(u)
+				}
+			case err := <-w.Errors:
+				send(dynconf.SourceUpdate{Err: err})
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Ping reads the file without reporting its changes, so they're still reported by Watch.
+func (s *Source) Ping(ctx context.Context) error {
+	_, err := s.read()
+	return err
+}
+
+// Close does nothing since the watcher is closed when the watch is stopped.
+func (s *Source) Close() error {
+	return nil
+}
+
+// refresh rereads the file and reports how the settings changed since the last time.
+func (s *Source) refresh() dynconf.SourceUpdate {
+	settings, err := s.read()
+	if err != nil {
+		return dynconf.SourceUpdate{Err: err}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := dynconf.SourceUpdate{Events: dynconf.DiffSettings(s.settings, settings)}
+	if len(u.Events) != 0 {
+		s.settings = settings
+		s.rev++
+	}
+	u.Revision = s.rev
+
+	return u
+}
+
+// read reads and parses the file according to its extension.
+func (s *Source) read() (map[string]string, error) {
+	b, err := os.ReadFile(s.name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ext := filepath.Ext(s.name); ext {
+	case ".json":
+		return parse.JSON(b)
+	case ".yaml", ".yml":
+		return parse.YAML(b)
+	case ".env":
+		return parse.Env(b)
+	default:
+		return nil, fmt.Errorf("unsupported file format %q", ext)
+	}
+}
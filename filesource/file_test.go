@@ -0,0 +1,147 @@
+package filesource
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/pooyakn/dynconf"
+)
+
+func TestRead(t *testing.T) {
+	tests := map[string]struct {
+		name string
+		in   string
+		want map[string]string
+	}{
+		"json": {
+			name: "curiosity.json",
+			in:   `{"name": "curiosity", "velocity": 5, "is_camera_enabled": true, "camera": {"fps": 30}, "tags": ["a", "b"], "empty": null}`,
+			want: map[string]string{
+				"name":              "curiosity",
+				"velocity":          "5",
+				"is_camera_enabled": "true",
+				"camera":            `{"fps":30}`,
+				"tags":              `["a","b"]`,
+				"empty":             "",
+			},
+		},
+		"yaml": {
+			name: "curiosity.yaml",
+			in:   "name: curiosity\nvelocity: 5.5\ncamera:\n  fps: 30\n",
+			want: map[string]string{
+				"name":     "curiosity",
+				"velocity": "5.5",
+				"camera":   `{"fps":30}`,
+			},
+		},
+		"env": {
+			name: ".env",
+			in:   "# Curiosity\n\nname=curiosity\nexport velocity = 5\ngreeting=\"hello\\nworld\"\nquote='a \"b\"'\nempty=\n",
+			want: map[string]string{
+				"name":     "curiosity",
+				"velocity": "5",
+				"greeting": "hello\nworld",
+				"quote":    `a "b"`,
+				"empty":    "",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			fname := filepath.Join(t.TempDir(), tc.name)
+			if err := os.WriteFile(fname, []byte(tc.in), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := New(fname).read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	tests := map[string]struct {
+		name string
+		in   string
+	}{
+		"unsupported": {
+			name: "curiosity.txt",
+			in:   "name=curiosity",
+		},
+		"json": {
+			name: "curiosity.json",
+			in:   `["curiosity"]`,
+		},
+		"env": {
+			name: "curiosity.env",
+			in:   "curiosity",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			fname := filepath.Join(t.TempDir(), tc.name)
+			if err := os.WriteFile(fname, []byte(tc.in), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := New(fname).read(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestSource(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "curiosity.json")
+	if err := os.WriteFile(fname, []byte(`{"velocity": 5}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := dynconf.New("", dynconf.WithLogger(logger), dynconf.WithSource(New(fname)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = c.Ready(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Integer("velocity", 0); got != 5 {
+		t.Fatalf("expected velocity 5 got %d", got)
+	}
+
+	// The file is replaced atomically.
+	tmp := fname + ".tmp"
+	if err = os.WriteFile(tmp, []byte(`{"name": "curiosity"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Rename(tmp, fname); err != nil {
+		t.Fatal(err)
+	}
+	err = c.AwaitFunc(ctx, func(settings map[string]string) bool {
+		_, ok := settings["velocity"]
+		return settings["name"] == "curiosity" && !ok
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
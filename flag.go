@@ -0,0 +1,18 @@
+package dynconf
+
+import "flag"
+
+// BindFlagSet overrides the settings with the flags explicitly set on the command line.
+// The settings map is keyed by the setting name which must match the flag name,
+// and its values point to the flag values, e.g., as returned by fs.String.
+// The flags that weren't set keep the settings intact.
+// It should be called after the flags were parsed.
+//
+// The precedence of a setting's value in getters is: flag > etcd > default.
+func (c *Config) BindFlagSet(fs *flag.FlagSet, settings map[string]*string) {
+	fs.Visit(func(f *flag.Flag) {
+		if p, ok := settings[f.Name]; ok && p != nil {
+			c.overrides.Store(f.Name, p)
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package dynconf
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/go-kit/log"
+)
+
+func TestBindFlagSet(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.settings.Store("velocity", "5")
+	c.settings.Store("is_camera_enabled", "true")
+
+	fs := flag.NewFlagSet("curiosity", flag.ContinueOnError)
+	velocity := fs.String("velocity", "1", "")
+	camera := fs.String("is_camera_enabled", "false", "")
+	if err = fs.Parse([]string{"-velocity=7"}); err != nil {
+		t.Fatal(err)
+	}
+	c.BindFlagSet(fs, map[string]*string{
+		"velocity":          velocity,
+		"is_camera_enabled": camera,
+	})
+
+	if got := c.Integer("velocity", 10); got != 7 {
+		t.Errorf("expected velocity %d got %d", 7, got)
+	}
+	// The flag wasn't set, so the etcd value is used.
+	if got := c.Boolean("is_camera_enabled", false); got != true {
+		t.Errorf("expected is_camera_enabled %t got %t", true, got)
+	}
+}
@@ -0,0 +1,185 @@
+// Package gcpsource provides dynconf sources backed by Google Cloud Firestore and Secret Manager,
+// so GCP-native services can use dynconf without etcd.
+package gcpsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pooyakn/dynconf"
+)
+
+// retryDelay is how long the sources wait before watching again after an error.
+const retryDelay = time.Second
+
+// Firestore is a dynconf.Source which keeps the settings as documents of a Firestore collection.
+// The document ID is the setting name, and the document's field is its value.
+// The strings are used as is, the other values are formatted,
+// and the maps and arrays are encoded as JSON to be read with Config.Struct.
+// The documents without the field are ignored.
+//
+// The collection is watched with real-time updates, i.e., query snapshots.
+// Firestore has no revisions, so the Source counts the updates instead.
+type Firestore struct {
+	client     *firestore.Client
+	collection string
+	field      string
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// settings are the last known settings used to detect the changes.
+	settings map[string]string
+	// rev is the number of updates.
+	rev int64
+}
+
+// NewFirestore returns a Source where the settings are the documents of the collection,
+// and the values are stored in the documents' field, e.g., "value".
+func NewFirestore(client *firestore.Client, collection, field string) *Firestore {
+	return &Firestore{
+		client:     client,
+		collection: collection,
+		field:      field,
+		settings:   make(map[string]string),
+	}
+}
+
+// Load fetches all the documents of the collection.
+func (s *Firestore) Load(ctx context.Context) (map[string]string, int64, error) {
+	docs, err := s.client.Collection(s.collection).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, 0, err
+	}
+	settings, err := s.decode(docs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = make(map[string]string, len(settings))
+	for k, v := range settings {
+		s.settings[k] = v
+	}
+	s.rev++
+
+	return settings, s.rev, nil
+}
+
+// Watch listens to the collection's snapshots and reports the settings' changes until ctx is canceled.
+func (s *Firestore) Watch(ctx context.Context) <-chan dynconf.SourceUpdate {
+	out := make(chan dynconf.SourceUpdate)
+	go func() {
+		defer close(out)
+		send := func(u dynconf.SourceUpdate) {
+			select {
+			case out <- u:
+			case <-ctx.Done():
+			}
+		}
+
+		for {
+			err := s.listen(ctx, send)
+			if ctx.Err() != nil {
+				return
+			}
+			send(dynconf.SourceUpdate{Err: err})
+
+			select {
+			case <-time.After(retryDelay):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// listen sends the changes of every collection snapshot until the snapshots fail.
+func (s *Firestore) listen(ctx context.Context, send func(dynconf.SourceUpdate)) error {
+	it := s.client.Collection(s.collection).Snapshots(ctx)
+	defer it.Stop()
+
+	for {
+		snap, err := it.Next()
+		if err != nil {
+			return err
+		}
+		docs, err := snap.Documents.GetAll()
+		if err != nil {
+			return err
+		}
+		settings, err := s.decode(docs)
+		if err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		u := dynconf.SourceUpdate{Events: dynconf.DiffSettings(s.settings, settings)}
+		if len(u.Events) != 0 {
+			s.settings = settings
+			s.rev++
+		}
+		u.Revision = s.rev
+		s.mu.Unlock()
+
+		send(u)
+	}
+}
+
+// Ping fetches the documents without reporting their changes, so they're still reported by Watch.
+func (s *Firestore) Ping(ctx context.Context) error {
+	docs, err := s.client.Collection(s.collection).Documents(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+	_, err = s.decode(docs)
+	return err
+}
+
+// Close closes the Firestore client.
+func (s *Firestore) Close() error {
+	return s.client.Close()
+}
+
+// decode returns the settings stored in the documents.
+func (s *Firestore) decode(docs []*firestore.DocumentSnapshot) (map[string]string, error) {
+	settings := make(map[string]string, len(docs))
+	for _, d := range docs {
+		v, ok := d.Data()[s.field]
+		if !ok {
+			continue
+		}
+		value, err := settingValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid setting %s: %w", d.Ref.ID, err)
+		}
+		settings[d.Ref.ID] = value
+	}
+
+	return settings, nil
+}
+
+// settingValue converts the decoded value to the setting's value.
+func settingValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case nil:
+		return "", nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(v)
+		return string(b), err
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
@@ -0,0 +1,91 @@
+package gcpsource
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSettingValue(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		want string
+	}{
+		"string": {
+			in:   "curiosity",
+			want: "curiosity",
+		},
+		"nil": {
+			in:   nil,
+			want: "",
+		},
+		"int": {
+			in:   int64(5),
+			want: "5",
+		},
+		"bool": {
+			in:   true,
+			want: "true",
+		},
+		"time": {
+			in:   time.Date(2021, 12, 1, 10, 0, 0, 0, time.UTC),
+			want: "2021-12-01T10:00:00Z",
+		},
+		"map": {
+			in:   map[string]interface{}{"fps": int64(30)},
+			want: `{"fps":30}`,
+		},
+		"array": {
+			in:   []interface{}{"a", "b"},
+			want: `["a","b"]`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := settingValue(tc.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %q got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSecretSetting(t *testing.T) {
+	tests := map[string]struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		"prefix": {
+			in:     "projects/mars/secrets/curiosity_password",
+			want:   "password",
+			wantOK: true,
+		},
+		"infix": {
+			in: "projects/mars/secrets/old_curiosity_password",
+		},
+		"prefix only": {
+			in: "projects/mars/secrets/curiosity_",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := secretSetting(tc.in, "curiosity_")
+			if got != tc.want || ok != tc.wantOK {
+				t.Errorf("expected %q %t got %q %t", tc.want, tc.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestNewSecretManagerInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if got := NewSecretManager(nil, "rovers", "curiosity_", interval).interval; got != DefaultInterval {
+			t.Errorf("expected interval %v for %v got %v", DefaultInterval, interval, got)
+		}
+	}
+}
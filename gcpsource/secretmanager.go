@@ -0,0 +1,169 @@
+package gcpsource
+
+import (
+	"context"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/pooyakn/dynconf"
+	"google.golang.org/api/iterator"
+)
+
+// DefaultInterval is how often SecretManager fetches the secrets if the interval passed to NewSecretManager isn't positive.
+const DefaultInterval = time.Minute
+
+// SecretManager is a dynconf.Source which keeps the settings as Secret Manager secrets whose IDs start with a prefix.
+// For example, if the prefix is curiosity_, then the latest version of curiosity_password secret is password setting.
+// Since the settings are credentials, Config should hide them in logs with dynconf.WithMaskedValues.
+//
+// Secret Manager can't be watched, so the secrets are polled instead,
+// and the Source counts the updates to use them as revisions.
+type SecretManager struct {
+	client   *secretmanager.Client
+	project  string
+	prefix   string
+	interval time.Duration
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// settings are the last known settings used to detect the changes.
+	settings map[string]string
+	// rev is the number of updates.
+	rev int64
+}
+
+// NewSecretManager returns a Source where the settings are the project's secrets with the prefix.
+// The secrets are fetched again every interval, or every DefaultInterval if interval isn't positive.
+func NewSecretManager(client *secretmanager.Client, project, prefix string, interval time.Duration) *SecretManager {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &SecretManager{
+		client:   client,
+		project:  project,
+		prefix:   prefix,
+		interval: interval,
+		settings: make(map[string]string),
+	}
+}
+
+// Load fetches the latest versions of the secrets.
+func (s *SecretManager) Load(ctx context.Context) (map[string]string, int64, error) {
+	settings, err := s.fetch(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = make(map[string]string, len(settings))
+	for k, v := range settings {
+		s.settings[k] = v
+	}
+	s.rev++
+
+	return settings, s.rev, nil
+}
+
+// Watch polls the secrets and reports the settings' changes until ctx is canceled.
+func (s *SecretManager) Watch(ctx context.Context) <-chan dynconf.SourceUpdate {
+	out := make(chan dynconf.SourceUpdate)
+	go func() {
+		defer close(out)
+
+		t := time.NewTicker(s.interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				return
+			}
+
+			var u dynconf.SourceUpdate
+			settings, err := s.fetch(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				u.Err = err
+			} else {
+				s.mu.Lock()
+				u.Events = dynconf.DiffSettings(s.settings, settings)
+				if len(u.Events) != 0 {
+					s.settings = settings
+					s.rev++
+				}
+				u.Revision = s.rev
+				s.mu.Unlock()
+			}
+
+			select {
+			case out <- u:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Ping fetches the secrets without reporting their changes, so they're still reported by Watch.
+func (s *SecretManager) Ping(ctx context.Context) error {
+	_, err := s.fetch(ctx)
+	return err
+}
+
+// Close closes the Secret Manager client.
+func (s *SecretManager) Close() error {
+	return s.client.Close()
+}
+
+// fetch returns the latest versions of the secrets with the prefix.
+func (s *SecretManager) fetch(ctx context.Context) (map[string]string, error) {
+	settings := make(map[string]string)
+	it := s.client.ListSecrets(ctx, &secretmanagerpb.ListSecretsRequest{
+		Parent: "projects/" + s.project,
+		Filter: "name:" + s.prefix,
+	})
+	for {
+		secret, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		setting, ok := secretSetting(secret.GetName(), s.prefix)
+		if !ok {
+			continue
+		}
+		r, err := s.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{
+			Name: secret.GetName() + "/versions/latest",
+		})
+		if err != nil {
+			return nil, err
+		}
+		settings[setting] = string(r.GetPayload().GetData())
+	}
+
+	return settings, nil
+}
+
+// secretSetting returns the setting name of the secret, e.g., projects/mars/secrets/curiosity_password is password.
+// The filter matches the prefix anywhere in the secret name, so ok is false if the secret ID doesn't start with it.
+func secretSetting(name, prefix string) (setting string, ok bool) {
+	id := path.Base(name)
+	if !strings.HasPrefix(id, prefix) || id == prefix {
+		return "", false
+	}
+
+	return id[len(prefix):], true
+}
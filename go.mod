@@ -1,20 +1,30 @@
 module github.com/pooyakn/dynconf
 
-go 1.17
+go 1.18
 
 require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/coreos/go-semver v0.3.0
 	github.com/go-kit/log v0.2.0
 	github.com/google/go-cmp v0.5.6
+	github.com/google/uuid v1.3.0
+	github.com/robfig/cron/v3 v3.0.1
+	github.com/shopspring/decimal v1.3.1
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	go.etcd.io/etcd/api/v3 v3.5.1
 	go.etcd.io/etcd/client/v3 v3.5.1
+	golang.org/x/time v0.3.0
+	google.golang.org/grpc v1.42.0
+	google.golang.org/protobuf v1.27.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/coreos/go-semver v0.3.0 // indirect
 	github.com/coreos/go-systemd/v22 v22.3.2 // indirect
 	github.com/go-logfmt/logfmt v0.5.1 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
-	go.etcd.io/etcd/api/v3 v3.5.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	go.etcd.io/etcd/client/pkg/v3 v3.5.1 // indirect
 	go.uber.org/atomic v1.9.0 // indirect
 	go.uber.org/multierr v1.7.0 // indirect
@@ -23,6 +33,5 @@ require (
 	golang.org/x/sys v0.0.0-20211124211545-fe61309f8881 // indirect
 	golang.org/x/text v0.3.7 // indirect
 	google.golang.org/genproto v0.0.0-20211118181313-81c1377c94b1 // indirect
-	google.golang.org/grpc v1.42.0 // indirect
-	google.golang.org/protobuf v1.27.1 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
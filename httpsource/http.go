@@ -0,0 +1,225 @@
+// Package httpsource provides a dynconf.Source which polls a JSON or YAML document over HTTP,
+// e.g., when etcd is unreachable behind a corporate proxy.
+package httpsource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pooyakn/dynconf"
+	"github.com/pooyakn/dynconf/internal/parse"
+)
+
+// DefaultInterval is how often the document is fetched if the interval passed to New isn't positive.
+const DefaultInterval = time.Minute
+
+// Source is a dynconf.Source which periodically fetches a document whose keys are the settings.
+// The document is decoded as YAML if its content type or URL path says so, otherwise as JSON.
+// The strings are used as is, the other scalars are formatted,
+// and the nested objects and arrays are encoded as JSON to be read with Config.Struct.
+//
+// The requests are conditional, i.e., ETag and Last-Modified of the last response are sent back,
+// so an unchanged document isn't downloaded again.
+// The Source counts the updates to use them as revisions.
+type Source struct {
+	client   *http.Client
+	url      string
+	interval time.Duration
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// settings are the last known settings used to detect the changes.
+	settings map[string]string
+	// rev is the number of updates.
+	rev int64
+	// etag and lastModified are the validators of the last fetched document.
+	etag         string
+	lastModified string
+}
+
+// New returns a Source which fetches the document from the URL every interval.
+// If client is nil, http.DefaultClient is used, and if interval isn't positive, DefaultInterval is used.
+func New(client *http.Client, url string, interval time.Duration) *Source {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Source{
+		client:   client,
+		url:      url,
+		interval: interval,
+		settings: make(map[string]string),
+	}
+}
+
+// Load fetches the document.
+func (s *Source) Load(ctx context.Context) (map[string]string, int64, error) {
+	settings, _, err := s.fetch(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = make(map[string]string, len(settings))
+	for k, v := range settings {
+		s.settings[k] = v
+	}
+	s.rev++
+
+	return settings, s.rev, nil
+}
+
+// Watch polls the document and reports the settings' changes until ctx is canceled.
+func (s *Source) Watch(ctx context.Context) <-chan dynconf.SourceUpdate {
+	out := make(chan dynconf.SourceUpdate)
+	go func() {
+		defer close(out)
+
+		t := time.NewTicker(s.interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				return
+			}
+
+			var u dynconf.SourceUpdate
+			settings, modified, err := s.fetch(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			s.mu.Lock()
+			switch {
+			case err != nil:
+				u.Err = err
+			case modified:
+				u.Events = dynconf.DiffSettings(s.settings, settings)
+				if len(u.Events) != 0 {
+					s.settings = settings
+					s.rev++
+				}
+			}
+			u.Revision = s.rev
+			s.mu.Unlock()
+
+			select {
+			case out <- u:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Close closes the idle connections of the HTTP client.
+func (s *Source) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// Ping requests the document without keeping it, so the changes are still reported by Watch.
+func (s *Source) Ping(ctx context.Context) error {
+	req, err := s.newRequest(ctx)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotModified {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// newRequest returns a request of the document which is conditional on the last fetched one.
+func (s *Source) newRequest(ctx context.Context) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	if s.lastModified != "" {
+		req.Header.Set("If-Modified-Since", s.lastModified)
+	}
+	s.mu.Unlock()
+
+	return req, nil
+}
+
+// fetch downloads and decodes the document unless it wasn't modified since the last time.
+func (s *Source) fetch(ctx context.Context) (settings map[string]string, modified bool, err error) {
+	req, err := s.newRequest(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, false, nil
+	default:
+		return nil, false, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	if isYAML(resp.Header.Get("Content-Type"), req.URL.Path) {
+		settings, err = parse.YAML(b)
+	} else {
+		settings, err = parse.JSON(b)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.mu.Lock()
+	s.etag = resp.Header.Get("ETag")
+	s.lastModified = resp.Header.Get("Last-Modified")
+	s.mu.Unlock()
+
+	return settings, true, nil
+}
+
+// isYAML reports whether the document is YAML according to its content type or URL path.
+func isYAML(contentType, urlPath string) bool {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch mt {
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return true
+	case "application/json":
+		return false
+	}
+
+	ext := path.Ext(urlPath)
+	return ext == ".yaml" || ext == ".yml"
+}
@@ -0,0 +1,180 @@
+package httpsource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/pooyakn/dynconf"
+)
+
+func TestSource(t *testing.T) {
+	var (
+		mu           sync.Mutex
+		doc          = `{"velocity": 5}`
+		version      = 1
+		notModified  int32
+		onUpdateHits int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		etag := strconv.Quote(strconv.Itoa(version))
+		if r.Header.Get("If-None-Match") == etag {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(doc))
+	}))
+	defer srv.Close()
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := dynconf.New(
+		"",
+		dynconf.WithLogger(logger),
+		dynconf.WithSource(New(srv.Client(), srv.URL, 10*time.Millisecond)),
+		dynconf.WithOnUpdate(func(map[string]string) {
+			atomic.AddInt32(&onUpdateHits, 1)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = c.Ready(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Integer("velocity", 0); got != 5 {
+		t.Fatalf("expected velocity 5 got %d", got)
+	}
+
+	// The unchanged document isn't downloaded again.
+	for atomic.LoadInt32(&notModified) < 3 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := atomic.LoadInt32(&onUpdateHits); got != 0 {
+		t.Errorf("expected no updates got %d", got)
+	}
+
+	mu.Lock()
+	doc = `{"name": "curiosity"}`
+	version++
+	mu.Unlock()
+	err = c.AwaitFunc(ctx, func(settings map[string]string) bool {
+		_, ok := settings["velocity"]
+		return settings["name"] == "curiosity" && !ok
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIsYAML(t *testing.T) {
+	tests := map[string]struct {
+		contentType string
+		path        string
+		want        bool
+	}{
+		"yaml content type": {
+			contentType: "application/yaml; charset=utf-8",
+			path:        "/configs/curiosity",
+			want:        true,
+		},
+		"json content type": {
+			contentType: "application/json",
+			path:        "/configs/curiosity.yaml",
+		},
+		"yaml path": {
+			contentType: "text/plain",
+			path:        "/configs/curiosity.yml",
+			want:        true,
+		},
+		"default": {
+			path: "/configs/curiosity",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := isYAML(tc.contentType, tc.path); got != tc.want {
+				t.Errorf("expected %t got %t", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestNewInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if got := New(nil, "http://localhost", interval).interval; got != DefaultInterval {
+			t.Errorf("expected interval %v for %v got %v", DefaultInterval, interval, got)
+		}
+	}
+}
+
+func TestPing(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		doc     = `{"velocity": 5}`
+		version = 1
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		etag := strconv.Quote(strconv.Itoa(version))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(doc))
+	}))
+	defer srv.Close()
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := dynconf.New("", dynconf.WithLogger(logger), dynconf.WithSource(New(srv.Client(), srv.URL, 50*time.Millisecond)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = c.Ready(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The change seen by Ping is still delivered by the watch.
+	mu.Lock()
+	doc = `{"velocity": 7}`
+	version++
+	mu.Unlock()
+	if err = c.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.AwaitFunc(ctx, func(settings map[string]string) bool { return settings["velocity"] == "7" }); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,102 @@
+// Package parse decodes the documents holding the settings, e.g., JSON files, into the settings' values.
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JSON decodes a JSON object whose keys are the settings.
+// The strings are used as is, the other scalars are formatted,
+// and the nested objects and arrays are encoded as JSON to be read with Config.Struct.
+func JSON(b []byte) (map[string]string, error) {
+	// The numbers are kept as they're written, e.g., 1000000 isn't formatted as 1e+06.
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var m map[string]interface{}
+	if err := d.Decode(&m); err != nil {
+		return nil, err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid data after top-level value")
+	}
+
+	return stringify(m)
+}
+
+// YAML decodes a YAML mapping whose keys are the settings, the values are converted as in JSON.
+func YAML(b []byte) (map[string]string, error) {
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	return stringify(m)
+}
+
+// Env decodes the KEY=VALUE lines, where the values can be quoted.
+// The blank lines and the lines starting with # are ignored.
+func Env(b []byte) (map[string]string, error) {
+	settings := make(map[string]string)
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		i := strings.IndexByte(line, '=')
+		if i < 1 {
+			return nil, fmt.Errorf("invalid line %d: %q", n, line)
+		}
+		k := strings.TrimSpace(line[:i])
+		v := strings.TrimSpace(line[i+1:])
+		if len(v) > 1 && v[0] == '"' && v[len(v)-1] == '"' {
+			uv, err := strconv.Unquote(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid line %d: %w", n, err)
+			}
+			v = uv
+		} else if len(v) > 1 && v[0] == '\'' && v[len(v)-1] == '\'' {
+			v = v[1 : len(v)-1]
+		}
+		settings[k] = v
+	}
+
+	return settings, sc.Err()
+}
+
+// stringify converts the decoded values to the settings' values.
+func stringify(m map[string]interface{}) (map[string]string, error) {
+	settings := make(map[string]string, len(m))
+	for k, v := range m {
+		switch v := v.(type) {
+		case string:
+			settings[k] = v
+		case nil:
+			settings[k] = ""
+		case json.Number:
+			settings[k] = v.String()
+		case float64:
+			settings[k] = strconv.FormatFloat(v, 'f', -1, 64)
+		case map[string]interface{}, []interface{}:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting %s: %w", k, err)
+			}
+			settings[k] = string(b)
+		default:
+			settings[k] = fmt.Sprint(v)
+		}
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,46 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJSON(t *testing.T) {
+	got, err := JSON([]byte(`{"velocity": 1000000, "id": 12345678, "ratio": 0.5, "big": 1e21, "name": "curiosity", "camera": {"fps": 30}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"velocity": "1000000",
+		"id":       "12345678",
+		"ratio":    "0.5",
+		"big":      "1e21",
+		"name":     "curiosity",
+		"camera":   `{"fps":30}`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	if _, err = JSON([]byte(`{"velocity": 5} {}`)); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestYAML(t *testing.T) {
+	got, err := YAML([]byte("velocity: 1000000\nid: 12345678\nratio: 1234567.5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"velocity": "1000000",
+		"id":       "12345678",
+		"ratio":    "1234567.5",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
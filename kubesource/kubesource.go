@@ -0,0 +1,223 @@
+// Package kubesource provides a dynconf.Source backed by a Kubernetes ConfigMap or Secret,
+// so services running in-cluster can use dynconf without etcd.
+package kubesource
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pooyakn/dynconf"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Source is a dynconf.Source which projects the data keys of a Kubernetes object into the settings.
+// The object is watched with an informer, and its absence means there are no settings.
+//
+// Kubernetes resource versions are opaque, so the Source counts the updates instead.
+type Source struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	// get fetches the object's data.
+	get func(ctx context.Context) (map[string]string, error)
+	// data returns the data of the object, ok is false if it's not the watched object.
+	data func(obj interface{}) (data map[string]string, ok bool)
+	// informer returns the informer of the object's kind.
+	informer func(f informers.SharedInformerFactory) cache.SharedIndexInformer
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// settings are the last known settings used to detect the changes.
+	settings map[string]string
+	// rev is the number of updates.
+	rev int64
+}
+
+// NewConfigMap returns a Source where the settings are the data keys of the ConfigMap.
+func NewConfigMap(client kubernetes.Interface, namespace, name string) *Source {
+	s := Source{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+		settings:  make(map[string]string),
+	}
+	s.get = func(ctx context.Context) (map[string]string, error) {
+		cm, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return cm.Data, nil
+	}
+	s.data = func(obj interface{}) (map[string]string, bool) {
+		cm, ok := obj.(*corev1.ConfigMap)
+		if !ok || cm.Namespace != namespace || cm.Name != name {
+			return nil, false
+		}
+		return cm.Data, true
+	}
+	s.informer = func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().ConfigMaps().Informer()
+	}
+
+	return &s
+}
+
+// NewSecret returns a Source where the settings are the decoded data keys of the Secret.
+// Since the settings are credentials, Config should hide them in logs with dynconf.WithMaskedValues.
+func NewSecret(client kubernetes.Interface, namespace, name string) *Source {
+	s := Source{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+		settings:  make(map[string]string),
+	}
+	s.get = func(ctx context.Context) (map[string]string, error) {
+		secret, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return secretData(secret), nil
+	}
+	s.data = func(obj interface{}) (map[string]string, bool) {
+		secret, ok := obj.(*corev1.Secret)
+		if !ok || secret.Namespace != namespace || secret.Name != name {
+			return nil, false
+		}
+		return secretData(secret), true
+	}
+	s.informer = func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Secrets().Informer()
+	}
+
+	return &s
+}
+
+// secretData returns the Secret's data as strings, the values are already decoded from base64 by the client.
+func secretData(secret *corev1.Secret) map[string]string {
+	data := make(map[string]string, len(secret.Data))
+	for k, v := range secret.Data {
+		data[k] = string(v)
+	}
+
+	return data
+}
+
+// Load fetches the object's data.
+func (s *Source) Load(ctx context.Context) (map[string]string, int64, error) {
+	settings, err := s.get(ctx)
+	if apierrors.IsNotFound(err) {
+		settings, err = map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = make(map[string]string, len(settings))
+	for k, v := range settings {
+		s.settings[k] = v
+	}
+	s.rev++
+
+	return settings, s.rev, nil
+}
+
+// Watch starts an informer watching the object and reports its data changes until ctx is canceled.
+func (s *Source) Watch(ctx context.Context) <-chan dynconf.SourceUpdate {
+	out := make(chan dynconf.SourceUpdate)
+	send := func(u dynconf.SourceUpdate) {
+		select {
+		case out <- u:
+		case <-ctx.Done():
+		}
+	}
+	apply := func(data map[string]string) {
+		if u, changed := s.apply(data); changed {
+			send(u)
+		}
+	}
+
+	f := informers.NewSharedInformerFactoryWithOptions(
+		s.client,
+		0,
+		informers.WithNamespace(s.namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.FieldSelector = fields.OneTermEqualSelector("metadata.name", s.name).String()
+		}),
+	)
+	inf := s.informer(f)
+	// The error handler can only be set before the informer is started.
+	_ = inf.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+		send(dynconf.SourceUpdate{Err: err})
+	})
+	_, _ = inf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if data, ok := s.data(obj); ok {
+				apply(data)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if data, ok := s.data(obj); ok {
+				apply(data)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if d, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = d.Obj
+			}
+			if _, ok := s.data(obj); ok {
+				apply(nil)
+			}
+		},
+	})
+
+	go func() {
+		defer close(out)
+		f.Start(ctx.Done())
+		<-ctx.Done()
+		f.Shutdown()
+	}()
+
+	return out
+}
+
+// Ping fetches the object without reporting its data changes, so they're still reported by Watch.
+// The object which doesn't exist yet is fine as Load treats it as empty.
+func (s *Source) Ping(ctx context.Context) error {
+	_, err := s.get(ctx)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// Close does nothing since the Kubernetes client doesn't need to be closed.
+func (s *Source) Close() error {
+	return nil
+}
+
+// apply replaces the last known settings with data and reports the changes.
+func (s *Source) apply(data map[string]string) (u dynconf.SourceUpdate, changed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u.Events = dynconf.DiffSettings(s.settings, data)
+	if len(u.Events) == 0 {
+		return u, false
+	}
+	s.settings = make(map[string]string, len(data))
+	for k, v := range data {
+		s.settings[k] = v
+	}
+	s.rev++
+	u.Revision = s.rev
+
+	return u, true
+}
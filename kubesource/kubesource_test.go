@@ -0,0 +1,131 @@
+package kubesource
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/pooyakn/dynconf"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestConfigMap(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "mars", Name: "curiosity"},
+		Data:       map[string]string{"velocity": "5"},
+	})
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := dynconf.New("", dynconf.WithLogger(logger), dynconf.WithSource(NewConfigMap(client, "mars", "curiosity")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = c.Ready(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Integer("velocity", 0); got != 5 {
+		t.Fatalf("expected velocity 5 got %d", got)
+	}
+
+	cms := client.CoreV1().ConfigMaps("mars")
+	// The other ConfigMaps must be ignored.
+	_, err = cms.Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "mars", Name: "perseverance"},
+		Data:       map[string]string{"velocity": "1"},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cms.Update(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "mars", Name: "curiosity"},
+		Data:       map[string]string{"name": "curiosity"},
+	}, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.AwaitFunc(ctx, func(settings map[string]string) bool {
+		_, ok := settings["velocity"]
+		return settings["name"] == "curiosity" && !ok
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = cms.Delete(ctx, "curiosity", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	err = c.AwaitFunc(ctx, func(settings map[string]string) bool {
+		return len(settings) == 0
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigMapNotFound(t *testing.T) {
+	s := NewConfigMap(fake.NewSimpleClientset(), "mars", "curiosity")
+	settings, _, err := s.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(settings) != 0 {
+		t.Errorf("expected no settings got %v", settings)
+	}
+}
+
+func TestSecret(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "mars", Name: "curiosity"},
+		Data:       map[string][]byte{"password": []byte("s3cr3t")},
+	})
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := dynconf.New(
+		"",
+		dynconf.WithLogger(logger),
+		dynconf.WithMaskedValues(),
+		dynconf.WithSource(NewSecret(client, "mars", "curiosity")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = c.Ready(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("password", ""); got != "s3cr3t" {
+		t.Fatalf("expected password s3cr3t got %s", got)
+	}
+
+	// The credentials are rotated.
+	_, err = client.CoreV1().Secrets("mars").Update(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "mars", Name: "curiosity"},
+		Data:       map[string][]byte{"password": []byte("n3w")},
+	}, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.AwaitFunc(ctx, func(settings map[string]string) bool {
+		return settings["password"] == "n3w"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,177 @@
+package dynconf
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// layeredSource is a Source which merges the settings of several sources.
+type layeredSource struct {
+	sources []Source
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// layers hold the last known settings of each source.
+	layers []map[string]string
+	// rev is the number of updates.
+	rev int64
+}
+
+// NewLayeredSource returns a Source composed of the given sources,
+// where a setting is looked up from the last source to the first one,
+// i.e., the later sources take precedence, e.g.,
+//
+//	NewLayeredSource(filesource.New("defaults.json"), NewEtcdSource(etcd, path), NewEnvSource("CURIOSITY_"))
+//
+// The changes from all the sources are watched, and a change is reported
+// only when it's not shadowed by a setting from a later source.
+// The layered source counts the updates to use them as revisions.
+func NewLayeredSource(sources ...Source) Source {
+	s := layeredSource{
+		sources: sources,
+		layers:  make([]map[string]string, len(sources)),
+	}
+	for i := range s.layers {
+		s.layers[i] = make(map[string]string)
+	}
+
+	return &s
+}
+
+// Load loads the settings from all the sources and merges them.
+func (s *layeredSource) Load(ctx context.Context) (map[string]string, int64, error) {
+	layers := make([]map[string]string, len(s.sources))
+	for i, src := range s.sources {
+		settings, _, err := src.Load(ctx)
+		if err != nil {
+			return nil, 0, fmt.Errorf("layer %d: %w", i, err)
+		}
+		layers[i] = settings
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	merged := make(map[string]string)
+	for i, settings := range layers {
+		s.layers[i] = make(map[string]string, len(settings))
+		for k, v := range settings {
+			s.layers[i][k] = v
+			merged[k] = v
+		}
+	}
+	s.rev++
+
+	return merged, s.rev, nil
+}
+
+// Watch watches all the sources and reports the changes of the merged settings until ctx is canceled.
+func (s *layeredSource) Watch(ctx context.Context) <-chan SourceUpdate {
+	type layerUpdate struct {
+		layer int
+		u     SourceUpdate
+	}
+	in := make(chan layerUpdate)
+	var wg sync.WaitGroup
+	for i, src := range s.sources {
+		wg.Add(1)
+		go func(i int, updates <-chan SourceUpdate) {
+			defer wg.Done()
+			for u := range updates {
+				select {
+				case in <- layerUpdate{layer: i, u: u}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(i, src.Watch(ctx))
+	}
+	go func() {
+		wg.Wait()
+		close(in)
+	}()
+
+	out := make(chan SourceUpdate)
+	go func() {
+		defer close(out)
+		for lu := range in {
+			select {
+			case out <- s.apply(lu.layer, lu.u):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Ping pings the sources implementing Pinger.
+func (s *layeredSource) Ping(ctx context.Context) error {
+	for i, src := range s.sources {
+		p, ok := src.(Pinger)
+		if !ok {
+			continue
+		}
+		if err := p.Ping(ctx); err != nil {
+			return fmt.Errorf("layer %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// Close closes all the sources.
+func (s *layeredSource) Close() error {
+	var errs MultiError
+	for i, src := range s.sources {
+		if err := src.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("layer %d: %w", i, err))
+		}
+	}
+	if len(errs) != 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// apply applies the update of the layer and returns the resulting changes of the merged settings.
+func (s *layeredSource) apply(layer int, u SourceUpdate) SourceUpdate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	merged := SourceUpdate{Err: u.Err, raw: u.raw}
+	for _, e := range u.Events {
+		old, oldOK := s.lookup(e.Setting)
+		if e.Deleted {
+			delete(s.layers[layer], e.Setting)
+		} else {
+			s.layers[layer][e.Setting] = e.Value
+		}
+
+		v, ok := s.lookup(e.Setting)
+		if ok == oldOK && v == old {
+			// The setting is shadowed by a later layer.
+			continue
+		}
+		merged.Events = append(merged.Events, SourceEvent{Setting: e.Setting, Value: v, Deleted: !ok})
+	}
+	if len(merged.Events) != 0 {
+		s.rev++
+	}
+	merged.Revision = s.rev
+
+	return merged
+}
+
+// lookup returns the setting from the last layer having it.
+func (s *layeredSource) lookup(setting string) (string, bool) {
+	for i := len(s.layers) - 1; i >= 0; i-- {
+		if v, ok := s.layers[i][setting]; ok {
+			return v, true
+		}
+	}
+
+	return "", false
+}
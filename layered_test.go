@@ -0,0 +1,113 @@
+package dynconf
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+func TestLayeredSource(t *testing.T) {
+	defaults := &fakeSource{
+		settings: map[string]string{"velocity": "1", "name": "curiosity"},
+		updates:  make(chan SourceUpdate),
+	}
+	remote := &fakeSource{
+		settings: map[string]string{"velocity": "5"},
+		updates:  make(chan SourceUpdate),
+	}
+	t.Setenv("CURIOSITY_IS_CAMERA_ENABLED", "true")
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New(
+		"",
+		WithLogger(logger),
+		WithSource(NewLayeredSource(defaults, remote, NewEnvSource("CURIOSITY_"))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err = c.Ready(ctx); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"velocity":          "5",
+		"name":              "curiosity",
+		"is_camera_enabled": "true",
+	}
+	if got := c.Settings(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v got %v", want, got)
+	}
+
+	changes := make(chan []Change, 1)
+	remove := c.AddListener(func(cc []Change) {
+		changes <- cc
+	})
+	defer remove()
+	tests := []struct {
+		src  *fakeSource
+		in   []SourceEvent
+		want []Change
+	}{
+		// The remote velocity is deleted, so the default one is used.
+		{
+			src:  remote,
+			in:   []SourceEvent{{Setting: "velocity", Deleted: true}},
+			want: []Change{{Setting: "velocity", Old: "5", New: "1"}},
+		},
+		{
+			src:  remote,
+			in:   []SourceEvent{{Setting: "velocity", Value: "7"}},
+			want: []Change{{Setting: "velocity", Old: "1", New: "7"}},
+		},
+		// The default velocity is shadowed by the remote one.
+		{
+			src: defaults,
+			in: []SourceEvent{
+				{Setting: "velocity", Value: "2"},
+				{Setting: "name", Value: "perseverance"},
+			},
+			want: []Change{{Setting: "name", Old: "curiosity", New: "perseverance"}},
+		},
+	}
+	for _, tc := range tests {
+		tc.src.updates <- SourceUpdate{Events: tc.in}
+
+		select {
+		case got := <-changes:
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+
+	if err = c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !defaults.closed || !remote.closed {
+		t.Error("expected the sources to be closed")
+	}
+}
+
+func TestEnvSource(t *testing.T) {
+	t.Setenv("CURIOSITY_VELOCITY", "5")
+	t.Setenv("CURIOSITY_", "empty name")
+	t.Setenv("PERSEVERANCE_VELOCITY", "1")
+
+	got, _, err := NewEnvSource("CURIOSITY_").Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"velocity": "5"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+}
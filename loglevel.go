@@ -0,0 +1,91 @@
+package dynconf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-kit/log/level"
+)
+
+// Level is a logging verbosity level.
+// Its values match log/slog levels, so a Level can be converted with slog.Level(l).
+type Level int
+
+// The levels ordered by severity.
+const (
+	LevelDebug Level = -4
+	LevelInfo  Level = 0
+	LevelWarn  Level = 4
+	LevelError Level = 8
+)
+
+// String returns the lowercase name of the level, e.g., "warn".
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+}
+
+// Allow returns the go-kit level filter letting through the records of the level and above,
+// e.g., level.NewFilter(logger, c.LogLevel("log_level", dynconf.LevelInfo).Allow()).
+func (l Level) Allow() level.Option {
+	switch {
+	case l <= LevelDebug:
+		return level.AllowDebug()
+	case l <= LevelInfo:
+		return level.AllowInfo()
+	case l <= LevelWarn:
+		return level.AllowWarn()
+	default:
+		return level.AllowError()
+	}
+}
+
+// parseLevel parses the case-insensitive level name, "warning" is accepted as well as "warn".
+func parseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q", s)
+	}
+}
+
+// LogLevel returns the logging level of the given setting, i.e., debug, info, warn, or error,
+// or defaultValue if it wasn't found or parsing failed.
+func (c *Config) LogLevel(setting string, defaultValue Level) Level {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	l, err := parseLevel(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid log level setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return l
+}
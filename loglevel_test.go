@@ -0,0 +1,70 @@
+package dynconf
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+func TestConfigLogLevel(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		want Level
+	}{
+		"debug": {
+			in:   "debug",
+			want: LevelDebug,
+		},
+		"uppercase": {
+			in:   "ERROR",
+			want: LevelError,
+		},
+		"warning": {
+			in:   "warning",
+			want: LevelWarn,
+		},
+		"unknown": {
+			in:   "trace",
+			want: LevelInfo,
+		},
+		"int": {
+			in:   0,
+			want: LevelInfo,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("log_level", tc.in)
+			if got := c.LogLevel("log_level", LevelInfo); tc.want != got {
+				t.Errorf("expected %s got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLevelAllow(t *testing.T) {
+	var buf bytes.Buffer
+	logger := level.NewFilter(log.NewLogfmtLogger(&buf), LevelWarn.Allow())
+
+	level.Info(logger).Log("msg", "skipped")
+	level.Warn(logger).Log("msg", "logged")
+
+	if got, want := buf.String(), "level=warn msg=logged\n"; got != want {
+		t.Errorf("expected %q got %q", want, got)
+	}
+}
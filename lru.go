@@ -0,0 +1,230 @@
+package dynconf
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fetchTimeout bounds fetching a setting from etcd when it's read for the first time, see WithMaxKeys.
+const fetchTimeout = time.Second
+
+// lru tracks the least recently used settings.
+type lru struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[string]*list.Element
+	// absent are the tracked settings which aren't in etcd, so they aren't fetched on every read.
+	absent map[string]struct{}
+	// revs are the etcd revisions the tracked settings are up to date with.
+	revs map[string]int64
+}
+
+func newLRU(size int) *lru {
+	return &lru{
+		size:   size,
+		order:  list.New(),
+		items:  make(map[string]*list.Element),
+		absent: make(map[string]struct{}),
+		revs:   make(map[string]int64),
+	}
+}
+
+// touch marks the setting as the most recently used one.
+// It returns the least recently used setting if it had to be evicted to fit the size.
+func (l *lru) touch(setting string) (evicted string, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, found := l.items[setting]; found {
+		l.order.MoveToFront(e)
+		return "", false
+	}
+	l.items[setting] = l.order.PushFront(setting)
+
+	if l.order.Len() <= l.size {
+		return "", false
+	}
+	e := l.order.Back()
+	l.order.Remove(e)
+	evicted, _ = e.Value.(string)
+	delete(l.items, evicted)
+	delete(l.absent, evicted)
+	delete(l.revs, evicted)
+
+	return evicted, true
+}
+
+// remove stops tracking the setting.
+func (l *lru) remove(setting string) {
+	l.mu.Lock()
+	if e, ok := l.items[setting]; ok {
+		l.order.Remove(e)
+		delete(l.items, setting)
+	}
+	delete(l.absent, setting)
+	delete(l.revs, setting)
+	l.mu.Unlock()
+}
+
+// tracked reports whether the setting is cached, either with its value or as absent.
+func (l *lru) tracked(setting string) bool {
+	l.mu.Lock()
+	_, ok := l.items[setting]
+	l.mu.Unlock()
+	return ok
+}
+
+// revision returns the etcd revision the tracked setting is up to date with.
+func (l *lru) revision(setting string) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.revs[setting]
+}
+
+// setRevision records the etcd revision the tracked setting is up to date with.
+func (l *lru) setRevision(setting string, rev int64) {
+	l.mu.Lock()
+	if _, ok := l.items[setting]; ok {
+		l.revs[setting] = rev
+	}
+	l.mu.Unlock()
+}
+
+// setAbsent marks the tracked setting as absent or present.
+func (l *lru) setAbsent(setting string, absent bool) {
+	l.mu.Lock()
+	if _, ok := l.items[setting]; ok && absent {
+		l.absent[setting] = struct{}{}
+	} else {
+		delete(l.absent, setting)
+	}
+	l.mu.Unlock()
+}
+
+// isAbsent reports whether the setting is known to be absent.
+func (l *lru) isAbsent(setting string) bool {
+	l.mu.Lock()
+	_, ok := l.absent[setting]
+	l.mu.Unlock()
+	return ok
+}
+
+// cache stores the setting and evicts the least recently used one if the cache is full.
+func (c *Config) cache(setting, value string) {
+	c.settings.Store(setting, value)
+	if evicted, ok := c.lru.touch(setting); ok {
+		c.evict(evicted)
+	}
+	c.lru.setAbsent(setting, false)
+}
+
+// cacheAbsent remembers that the setting is absent and evicts the least recently used one if the cache is full.
+func (c *Config) cacheAbsent(setting string) {
+	if evicted, ok := c.lru.touch(setting); ok {
+		c.evict(evicted)
+	}
+	c.lru.setAbsent(setting, true)
+}
+
+// evict drops the setting which is no longer tracked and stops watching it.
+func (c *Config) evict(setting string) {
+	c.settings.Delete(setting)
+	if cancel, ok := c.keyWatches[setting]; ok {
+		cancel()
+		delete(c.keyWatches, setting)
+	}
+}
+
+// lookupLazy returns the cached setting, or fetches it from etcd if it isn't cached yet.
+// The settings which aren't in etcd are cached as absent.
+func (c *Config) lookupLazy(setting string) (interface{}, bool) {
+	if v, ok := c.settings.Load(setting); ok {
+		c.lru.touch(setting)
+		return v, true
+	}
+	if c.lru.isAbsent(setting) {
+		c.lru.touch(setting)
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	r, err := c.etcd.Get(ctx, c.path+setting)
+	cancel()
+	c.setState(err)
+	if err != nil {
+		c.logger.Log("msg", "dynconf failed to fetch setting", "path", c.path, "setting", setting, "err", err)
+		return nil, false
+	}
+
+	found := len(r.Kvs) != 0
+	var value string
+	if found {
+		value = c.expand(setting, string(r.Kvs[0].Value))
+		if !c.valid(setting, value) {
+			return nil, false
+		}
+	}
+	c.cacheFetched(setting, value, found, r.Header.Revision)
+	if !found {
+		return nil, false
+	}
+
+	return value, true
+}
+
+// cacheFetched caches the setting fetched at the etcd revision and starts watching it from the next revision,
+// so none of its changes are missed. The setting which is already cached at a later revision is kept.
+// It's serialized with update by lazyMu.
+func (c *Config) cacheFetched(setting, value string, found bool, rev int64) {
+	c.lazyMu.Lock()
+	defer c.lazyMu.Unlock()
+
+	if c.lru.tracked(setting) && c.lru.revision(setting) >= rev {
+		c.lru.touch(setting)
+		return
+	}
+	if found {
+		c.cache(setting, value)
+	} else {
+		c.cacheAbsent(setting)
+	}
+	c.lru.setRevision(setting, rev)
+	if _, ok := c.keyWatches[setting]; !ok {
+		c.watchKey(setting, rev)
+	}
+}
+
+// watchKey watches the cached setting for the changes made after the revision until it's evicted,
+// so only the cached settings are watched. It's called under lazyMu.
+func (c *Config) watchKey(setting string, rev int64) {
+	ctx, cancel := context.WithCancel(c.watchCtx)
+	c.keyWatches[setting] = cancel
+
+	go func() {
+		for r := range c.etcd.Watch(ctx, c.path+setting, clientv3.WithRev(rev+1), clientv3.WithPrevKV()) {
+			if r.CompactRevision != 0 {
+				// The changes since the revision were compacted, so the setting is fetched again on the next read.
+				c.lazyMu.Lock()
+				if ctx.Err() == nil {
+					c.lru.remove(setting)
+					c.evict(setting)
+				}
+				c.lazyMu.Unlock()
+				return
+			}
+
+			u := newEtcdUpdate(c.path, r.Events, r.Header.Revision)
+			u.Err = r.Err()
+			if u.Err != nil {
+				c.logger.Log("msg", "dynconf watch error", "path", c.path, "setting", setting, "err", u.Err)
+			}
+			c.setState(u.Err)
+			c.update(u)
+		}
+	}()
+}
@@ -0,0 +1,330 @@
+package dynconf
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/google/go-cmp/cmp"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestMaxKeysEviction(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithMaxKeys(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.cache("velocity", "5")
+	c.cache("is_camera_enabled", "true")
+	// The velocity becomes the most recently used setting.
+	if got := c.Integer("velocity", 10); got != 5 {
+		t.Errorf("expected velocity %d got %d", 5, got)
+	}
+	c.cache("name", "curiosity")
+
+	want := map[string]string{
+		"velocity": "5",
+		"name":     "curiosity",
+	}
+	if diff := cmp.Diff(want, c.Settings()); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestMaxKeysFetch(t *testing.T) {
+	etcd := newEtcdClient(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if r, err := etcd.Put(ctx, "/configs/curiosity/velocity", "5"); err != nil {
+		t.Fatalf("failed to put velocity=5 setting: %v %v", err, r)
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithEtcdClient(etcd), WithLogger(logger), WithMaxKeys(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if got := c.Settings(); got != nil {
+		t.Errorf("expected no cached settings got %v", got)
+	}
+	if got := c.Integer("velocity", 10); got != 5 {
+		t.Errorf("expected velocity %d got %d", 5, got)
+	}
+	if _, ok := c.settings.Load("velocity"); !ok {
+		t.Errorf("expected velocity to be cached")
+	}
+}
+
+func TestMaxKeysAbsent(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithMaxKeys(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.cacheFetched("mission", "", false, 1)
+	// The absent setting isn't fetched again.
+	if _, ok := c.lookupLazy("mission"); ok {
+		t.Error("expected mission to be absent")
+	}
+
+	var changes []Change
+	c.AddListener(func(cc []Change) { changes = append(changes, cc...) })
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/mission", "mars", 2)}, 2))
+	if got := c.String("mission", ""); got != "mars" {
+		t.Errorf("expected mission mars got %q", got)
+	}
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{deleteEvent("/configs/curiosity/mission", 3)}, 3))
+	if !c.lru.isAbsent("mission") {
+		t.Error("expected deleted mission to be cached as absent")
+	}
+	// The deletion of an absent setting isn't reported.
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{deleteEvent("/configs/curiosity/mission", 4)}, 4))
+
+	want := []Change{
+		{Setting: "mission", New: "mars"},
+		{Setting: "mission", Old: "mars", Deleted: true},
+	}
+	if diff := cmp.Diff(want, changes); diff != "" {
+		t.Error(diff)
+	}
+
+	// The absent settings are evicted as well.
+	c.cache("velocity", "5")
+	c.cache("name", "curiosity")
+	if c.lru.isAbsent("mission") {
+		t.Error("expected mission to be evicted")
+	}
+}
+
+func TestCacheFetchedStale(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithMaxKeys(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.cacheFetched("velocity", "5", true, 5)
+	// The velocity fetched concurrently at an earlier revision doesn't overwrite the cached one.
+	c.cacheFetched("velocity", "3", true, 4)
+	if got := c.String("velocity", ""); got != "5" {
+		t.Errorf("expected velocity 5 got %q", got)
+	}
+	// The change made before the velocity was fetched is skipped, e.g., the change reported by an evicted watch.
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/velocity", "4", 4)}, 6))
+	if got := c.String("velocity", ""); got != "5" {
+		t.Errorf("expected velocity 5 got %q", got)
+	}
+	c.cacheFetched("velocity", "7", true, 7)
+	if got := c.String("velocity", ""); got != "7" {
+		t.Errorf("expected velocity 7 got %q", got)
+	}
+}
+
+// fakeKV is an etcd KV whose Get returns the settings at the revision.
+type fakeKV struct {
+	clientv3.KV
+	kvs map[string]string
+	rev int64
+}
+
+func (kv *fakeKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	r := clientv3.GetResponse{Header: &etcdserverpb.ResponseHeader{Revision: kv.rev}}
+	if v, ok := kv.kvs[key]; ok {
+		r.Kvs = append(r.Kvs, &mvccpb.KeyValue{Key: []byte(key), Value: []byte(v), ModRevision: kv.rev})
+	}
+	return &r, nil
+}
+
+// fakeWatcher is an etcd Watcher which records the watches, the responses are sent by tests.
+type fakeWatcher struct {
+	clientv3.Watcher
+
+	mu      sync.Mutex
+	watches map[string]fakeWatch
+}
+
+// fakeWatch is a watch of a single key.
+type fakeWatch struct {
+	op  clientv3.Op
+	ctx context.Context
+	ch  chan clientv3.WatchResponse
+}
+
+func (w *fakeWatcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	ch := make(chan clientv3.WatchResponse)
+	w.mu.Lock()
+	w.watches[key] = fakeWatch{op: clientv3.OpGet(key, opts...), ctx: ctx, ch: ch}
+	w.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		close(ch)
+	}()
+	return ch
+}
+
+func (w *fakeWatcher) watch(key string) (fakeWatch, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fw, ok := w.watches[key]
+	return fw, ok
+}
+
+// waitWatch waits for the key to be watched.
+func (w *fakeWatcher) waitWatch(t *testing.T, key string) fakeWatch {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if fw, ok := w.watch(key); ok {
+			return fw
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %s to be watched", key)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (w *fakeWatcher) Close() error {
+	return nil
+}
+
+func TestMaxKeysWatch(t *testing.T) {
+	etcd, err := clientv3.New(clientv3.Config{
+		Endpoints: []string{"127.0.0.1:1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	etcd.KV = &fakeKV{kvs: map[string]string{"/configs/curiosity/velocity": "5"}, rev: 10}
+	watcher := &fakeWatcher{watches: make(map[string]fakeWatch)}
+	etcd.Watcher = watcher
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithEtcdClient(etcd), WithLogger(logger), WithMaxKeys(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if got := c.Integer("velocity", 0); got != 5 {
+		t.Fatalf("expected velocity 5 got %d", got)
+	}
+	// Only the cached velocity is watched from the revision after it was fetched.
+	velocity := watcher.waitWatch(t, "/configs/curiosity/velocity")
+	if velocity.op.Rev() != 11 || len(velocity.op.RangeBytes()) != 0 {
+		t.Errorf("expected velocity to be watched from revision 11 got %d %q", velocity.op.Rev(), velocity.op.RangeBytes())
+	}
+	watcher.mu.Lock()
+	if len(watcher.watches) != 1 {
+		t.Errorf("expected a single watch got %v", watcher.watches)
+	}
+	watcher.mu.Unlock()
+
+	velocity.ch <- clientv3.WatchResponse{
+		Header: etcdserverpb.ResponseHeader{Revision: 12},
+		Events: []*clientv3.Event{putEvent("/configs/curiosity/velocity", "7", 12)},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err = c.AwaitFunc(ctx, func(settings map[string]string) bool { return settings["velocity"] == "7" }); err != nil {
+		t.Fatal(err)
+	}
+
+	// The uncached name isn't watched until it's read, and then the evicted velocity isn't watched anymore.
+	if _, ok := watcher.watch("/configs/curiosity/name"); ok {
+		t.Error("expected name not to be watched")
+	}
+	if got := c.String("name", "curiosity"); got != "curiosity" {
+		t.Errorf("expected default name got %q", got)
+	}
+	watcher.waitWatch(t, "/configs/curiosity/name")
+	select {
+	case <-velocity.ctx.Done():
+	case <-time.After(time.Second):
+		t.Error("expected the evicted velocity not to be watched")
+	}
+}
+
+func TestMaxKeysKeys(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithMaxKeys(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// Only the cached settings are listed, so the evicted velocity and the absent mission aren't.
+	c.cache("velocity", "5")
+	c.cache("name", "curiosity")
+	c.cacheAbsent("mission")
+	if diff := cmp.Diff([]string{"name"}, c.Keys()); diff != "" {
+		t.Error(diff)
+	}
+	if got := c.Len(); got != 1 {
+		t.Errorf("expected 1 setting got %d", got)
+	}
+}
+
+func TestMaxKeysRequiredKeys(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithMaxKeys(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.cache("velocity", "5")
+	c.cacheAbsent("mission")
+	c.requiredKeys = []string{"velocity"}
+	if err = c.checkRequiredKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.requiredKeys = []string{"velocity", "mission"}
+	if err = c.checkRequiredKeys(); err == nil {
+		t.Error("expected mission to be missing")
+	}
+}
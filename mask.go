@@ -0,0 +1,67 @@
+package dynconf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-kit/log"
+)
+
+// maskedValue replaces the settings' values in logs, see WithMaskedValues.
+const maskedValue = "******"
+
+// maskedLogger replaces the settings' values with maskedValue before logging.
+type maskedLogger struct {
+	logger log.Logger
+}
+
+// Log masks the values of "value" and "settings" keys.
+// The errors are logged as strings with the value masked since they often quote it, e.g., strconv errors.
+func (l maskedLogger) Log(keyvals ...interface{}) error {
+	var value string
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		switch keyvals[i] {
+		case "value", "settings":
+			value = fmt.Sprint(keyvals[i+1])
+			keyvals[i+1] = maskedValue
+		}
+	}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if err, ok := keyvals[i+1].(error); ok && keyvals[i] == "err" && value != "" {
+			keyvals[i+1] = strings.ReplaceAll(err.Error(), value, maskedValue)
+		}
+	}
+
+	return l.logger.Log(keyvals...)
+}
+
+// maskedErr returns err to be logged, or maskedValue if the values are masked.
+// It's used for the errors that may quote any part of a setting's value without logging the value,
+// e.g., the decoders' syntax errors, so maskedLogger can't redact them.
+func (c *Config) maskedErr(err error) interface{} {
+	if c.maskValues {
+		return maskedValue
+	}
+
+	return err
+}
+
+// maskSettings replaces the settings' values in s with maskedValue.
+// The longer values are replaced first, so a value containing another one isn't partially revealed.
+func maskSettings(s string, settings map[string]string) string {
+	values := make([]string, 0, len(settings))
+	for _, v := range settings {
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	oldnew := make([]string, 0, 2*len(values))
+	for _, v := range values {
+		oldnew = append(oldnew, v, maskedValue)
+	}
+
+	return strings.NewReplacer(oldnew...).Replace(s)
+}
@@ -0,0 +1,126 @@
+package dynconf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestWithMaskedValues(t *testing.T) {
+	var buf bytes.Buffer
+	c, err := New("/configs/curiosity/", WithLogger(log.NewLogfmtLogger(&buf)), WithMaskedValues())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.settings.Store("password", "s3cr3t")
+	if got := c.Integer("password", 0); got != 0 {
+		t.Fatalf("expected default value got %d", got)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "s3cr3t") {
+		t.Errorf("expected masked value got %s", out)
+	}
+	if !strings.Contains(out, "setting=password value=******") {
+		t.Errorf("expected masked value log got %s", out)
+	}
+}
+
+func TestWithMaskedValuesValidator(t *testing.T) {
+	var buf bytes.Buffer
+	v := ValidatorFunc(func(settings map[string]string) error {
+		return fmt.Errorf("invalid password %q", settings["password"])
+	})
+	c, err := New("/configs/curiosity/", WithLogger(log.NewLogfmtLogger(&buf)), WithMaskedValues(), WithValidator(v, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/password", "s3cr3t", 2)}, 2))
+
+	out := buf.String()
+	if strings.Contains(out, "s3cr3t") {
+		t.Errorf("expected masked value got %s", out)
+	}
+	if !strings.Contains(out, `invalid password \"******\"`) {
+		t.Errorf("expected masked error log got %s", out)
+	}
+}
+
+// pin fails to decode quoting the data, as the decoders' errors do.
+type pin struct{}
+
+func (p *pin) UnmarshalJSON(data []byte) error {
+	return fmt.Errorf("invalid pin %s", data)
+}
+
+func TestWithMaskedValuesStruct(t *testing.T) {
+	var buf bytes.Buffer
+	c, err := New("/configs/curiosity/", WithLogger(log.NewLogfmtLogger(&buf)), WithMaskedValues())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.settings.Store("pin", `"s3cr3t"`)
+	var p pin
+	c.StructOrDefault("pin", &p, pin{})
+	Register(c, "pin", pin{}).Close()
+
+	out := buf.String()
+	if strings.Contains(out, "s3cr3t") {
+		t.Errorf("expected masked error got %s", out)
+	}
+	if got := strings.Count(out, "setting=pin err=******"); got != 2 {
+		t.Errorf("expected 2 masked error logs got %s", out)
+	}
+}
+
+func TestWithMaskedValuesResolver(t *testing.T) {
+	var buf bytes.Buffer
+	resolve := func(ctx context.Context, ref string) (string, error) {
+		return "", fmt.Errorf("secret %s not found", ref)
+	}
+	c, err := New("/configs/curiosity/", WithLogger(log.NewLogfmtLogger(&buf)), WithMaskedValues(), WithResolver("secretref", resolve))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.settings.Store("password", "secretref://aws/prod/db-password")
+	if got := c.ResolvedString(context.Background(), "password", "default"); got != "default" {
+		t.Fatalf("expected default value got %q", got)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "db-password") {
+		t.Errorf("expected masked reference got %s", out)
+	}
+	if !strings.Contains(out, `err="secret ****** not found"`) {
+		t.Errorf("expected masked error log got %s", out)
+	}
+}
@@ -0,0 +1,163 @@
+package dynconf
+
+import (
+	"math/big"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The Must getters panic if a setting is missing or invalid.
+// They are meant for the program initialization where proceeding without the setting is meaningless,
+// and shouldn't be used to read the settings that might change at runtime.
+
+// MustString returns the string value of the given setting,
+// or panics if it wasn't found.
+func (c *Config) MustString(setting string) string {
+	v, err := c.StringRequired(setting)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// MustBoolean returns the boolean value of the given setting,
+// or panics if it wasn't found or parsing failed.
+func (c *Config) MustBoolean(setting string) bool {
+	v, err := c.BooleanRequired(setting)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// MustInteger returns the integer value of the given setting,
+// or panics if it wasn't found or parsing failed.
+func (c *Config) MustInteger(setting string) int {
+	v, err := c.IntegerRequired(setting)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// MustInt64 returns the int64 value of the given setting,
+// or panics if it wasn't found or parsing failed.
+func (c *Config) MustInt64(setting string) int64 {
+	v, err := c.Int64Required(setting)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// MustFloat returns the float value of the given setting,
+// or panics if it wasn't found or parsing failed.
+func (c *Config) MustFloat(setting string) float64 {
+	v, err := c.FloatRequired(setting)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// MustDuration returns the duration value of the given setting,
+// or panics if it wasn't found or parsing failed.
+func (c *Config) MustDuration(setting string) time.Duration {
+	v, err := c.DurationRequired(setting)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// MustUint returns the unsigned integer value of the given setting,
+// or panics if it wasn't found or parsing failed.
+func (c *Config) MustUint(setting string) uint {
+	v, err := c.UintRequired(setting)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// MustUint64 returns the uint64 value of the given setting,
+// or panics if it wasn't found or parsing failed.
+func (c *Config) MustUint64(setting string) uint64 {
+	v, err := c.Uint64Required(setting)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// MustFloat32 returns the float32 value of the given setting,
+// or panics if it wasn't found or parsing failed.
+func (c *Config) MustFloat32(setting string) float32 {
+	v, err := c.Float32Required(setting)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// MustBigInt returns the big integer value of the given setting,
+// or panics if it wasn't found or parsing failed.
+func (c *Config) MustBigInt(setting string) *big.Int {
+	v, err := c.BigIntRequired(setting)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// MustUUID returns the UUID value of the given setting,
+// or panics if it wasn't found or parsing failed.
+func (c *Config) MustUUID(setting string) uuid.UUID {
+	v, err := c.UUIDRequired(setting)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// MustURL returns the URL value of the given setting,
+// or panics if it wasn't found or parsing failed.
+func (c *Config) MustURL(setting string) *url.URL {
+	v, err := c.URLRequired(setting)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// MustDate returns the date value of the given setting,
+// or panics if it wasn't found or parsing failed.
+func (c *Config) MustDate(setting string, format string) time.Time {
+	v, err := c.DateRequired(setting, format)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// MustStringArray returns the string array value of the given setting,
+// or panics if it wasn't found or parsing failed.
+func (c *Config) MustStringArray(setting string, delimiter string) []string {
+	v, err := c.StringArrayRequired(setting, delimiter)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// MustIntegerArray returns the integer array value of the given setting,
+// or panics if it wasn't found or parsing failed.
+func (c *Config) MustIntegerArray(setting string, delimiter string) []int {
+	v, err := c.IntegerArrayRequired(setting, delimiter)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
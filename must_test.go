@@ -0,0 +1,64 @@
+package dynconf
+
+import (
+	"os"
+	"testing"
+
+	"github.com/go-kit/log"
+)
+
+// mustPanic reports whether f panicked.
+func mustPanic(f func()) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+		}
+	}()
+	f()
+	return false
+}
+
+func TestMust(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.settings.Store("velocity", "5")
+	c.settings.Store("name", "curiosity")
+	c.settings.Store("cameras", "1,2")
+
+	if got := c.MustInteger("velocity"); got != 5 {
+		t.Errorf("expected velocity %d got %d", 5, got)
+	}
+	if got := c.MustString("name"); got != "curiosity" {
+		t.Errorf("expected name %q got %q", "curiosity", got)
+	}
+	if got := c.MustIntegerArray("cameras", ","); len(got) != 2 {
+		t.Errorf("expected 2 cameras got %v", got)
+	}
+
+	tests := map[string]func(){
+		"missing string":  func() { c.MustString("mission") },
+		"missing integer": func() { c.MustInteger("mission") },
+		"invalid integer": func() { c.MustInteger("name") },
+		"invalid boolean": func() { c.MustBoolean("velocity") },
+		"invalid uint":    func() { c.MustUint("name") },
+		"invalid uuid":    func() { c.MustUUID("velocity") },
+		"missing url":     func() { c.MustURL("mission") },
+		"invalid array":   func() { c.MustIntegerArray("name", ",") },
+	}
+	for name, f := range tests {
+		t.Run(name, func(t *testing.T) {
+			if !mustPanic(f) {
+				t.Errorf("expected panic")
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package dynconf
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// loadFile stores the settings from the JSON file.
+func (c *Config) loadFile(name string) error {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	var ss map[string]string
+	if err = json.Unmarshal(b, &ss); err != nil {
+		return err
+	}
+	for k, v := range ss {
+		c.settings.Store(k, v)
+	}
+
+	return nil
+}
+
+// saveFile writes the settings to the JSON file atomically,
+// i.e., to a temporary file which then replaces the original one.
+func (c *Config) saveFile(name string) error {
+	b, err := json.Marshal(c.Settings())
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), name)
+}
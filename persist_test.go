@@ -0,0 +1,62 @@
+package dynconf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPersistOnClose(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "curiosity.json")
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithPersistOnClose(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.settings.Store("velocity", "5")
+	c.settings.Store("is_camera_enabled", "true")
+	if err = c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = New("/configs/curiosity/", WithLogger(logger), WithBootstrapFile(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	want := map[string]string{
+		"velocity":          "5",
+		"is_camera_enabled": "true",
+	}
+	if diff := cmp.Diff(want, c.Settings()); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestBootstrapFileMissing(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "curiosity.json")
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithBootstrapFile(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if got := c.Settings(); got != nil {
+		t.Errorf("expected nil got %v", got)
+	}
+}
@@ -0,0 +1,238 @@
+// Package pgsource provides a dynconf.Source backed by a PostgreSQL table,
+// for shops that don't want a separate key-value store.
+package pgsource
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pooyakn/dynconf"
+)
+
+const (
+	// channel is the notification channel where the paths of the changed settings are sent.
+	channel = "dynconf"
+	// minReconnectInterval and maxReconnectInterval bound the listener's reconnection backoff.
+	minReconnectInterval = time.Second
+	maxReconnectInterval = time.Minute
+)
+
+// DefaultInterval is how often the settings are polled if the interval passed to New isn't positive.
+const DefaultInterval = time.Minute
+
+// Source is a dynconf.Source which keeps the settings as rows of a table with path, setting, and value columns,
+// where path is the path to a project's config, e.g., /configs/curiosity/, see CreateTable.
+//
+// The table's trigger notifies the listeners about the changes with NOTIFY,
+// and the settings are also polled in case a notification is missed, e.g., while reconnecting.
+// The Source counts the updates to use them as revisions.
+type Source struct {
+	db       *sql.DB
+	dsn      string
+	query    string
+	path     string
+	interval time.Duration
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// settings are the last known settings used to detect the changes.
+	settings map[string]string
+	// rev is the number of updates.
+	rev int64
+}
+
+// New returns a Source which reads the settings of the path from the table.
+// The notifications are received on a dedicated connection opened with dsn,
+// if dsn is empty, the settings are only polled every interval.
+// If interval isn't positive, DefaultInterval is used.
+func New(db *sql.DB, dsn, table, path string, interval time.Duration) *Source {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Source{
+		db:       db,
+		dsn:      dsn,
+		query:    fmt.Sprintf("SELECT setting, value FROM %s WHERE path = $1", pq.QuoteIdentifier(table)),
+		path:     path,
+		interval: interval,
+		settings: make(map[string]string),
+	}
+}
+
+// CreateTable creates the settings table unless it exists,
+// and a trigger notifying the listeners when the table is modified.
+func CreateTable(ctx context.Context, db *sql.DB, table string) error {
+	t := pq.QuoteIdentifier(table)
+	fn := pq.QuoteIdentifier(table + "_notify")
+	stmts := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			path text NOT NULL,
+			setting text NOT NULL,
+			value text NOT NULL,
+			PRIMARY KEY (path, setting)
+		)`, t),
+		fmt.Sprintf(`CREATE OR REPLACE FUNCTION %s() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP = 'DELETE' THEN
+				PERFORM pg_notify('%s', OLD.path);
+			ELSE
+				PERFORM pg_notify('%s', NEW.path);
+			END IF;
+			IF TG_OP = 'UPDATE' AND OLD.path <> NEW.path THEN
+				PERFORM pg_notify('%s', OLD.path);
+			END IF;
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql`, fn, channel, channel, channel),
+		fmt.Sprintf(`DROP TRIGGER IF EXISTS %s ON %s`, fn, t),
+		fmt.Sprintf(`CREATE TRIGGER %s AFTER INSERT OR UPDATE OR DELETE ON %s FOR EACH ROW EXECUTE PROCEDURE %s()`, fn, t, fn),
+	}
+	for _, stmt := range stmts {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Load reads the settings of the path.
+func (s *Source) Load(ctx context.Context) (map[string]string, int64, error) {
+	settings, err := s.fetch(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = make(map[string]string, len(settings))
+	for k, v := range settings {
+		s.settings[k] = v
+	}
+	s.rev++
+
+	return settings, s.rev, nil
+}
+
+// Watch rereads the settings when they're changed or every interval,
+// and reports the settings' changes until ctx is canceled.
+func (s *Source) Watch(ctx context.Context) <-chan dynconf.SourceUpdate {
+	out := make(chan dynconf.SourceUpdate)
+	go func() {
+		defer close(out)
+		send := func(u dynconf.SourceUpdate) bool {
+			select {
+			case out <- u:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var notifications <-chan *pq.Notification
+		// listenErrs holds the listener's last connection error.
+		listenErrs := make(chan error, 1)
+		if s.dsn != "" {
+			l := pq.NewListener(s.dsn, minReconnectInterval, maxReconnectInterval, func(_ pq.ListenerEventType, err error) {
+				if err == nil {
+					return
+				}
+				select {
+				case listenErrs <- err:
+				default:
+				}
+			})
+			defer l.Close()
+			if err := l.Listen(channel); err != nil && !send(dynconf.SourceUpdate{Err: err}) {
+				return
+			}
+			notifications = l.NotificationChannel()
+		}
+
+		t := time.NewTicker(s.interval)
+		defer t.Stop()
+		for {
+			select {
+			case n := <-notifications:
+				// The notification is nil after reconnecting since the changes might have been missed.
+				if n != nil && n.Extra != s.path {
+					continue
+				}
+			case err := <-listenErrs:
+				if !send(dynconf.SourceUpdate{Err: err}) {
+					return
+				}
+				continue
+			case <-t.C:
+			case <-ctx.Done():
+				return
+			}
+
+			if u, ok := s.refresh(ctx); ok && !send(u) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Ping reads the settings without reporting their changes, so they're still reported by Watch.
+func (s *Source) Ping(ctx context.Context) error {
+	_, err := s.fetch(ctx)
+	return err
+}
+
+// Close does nothing since the database is owned by the caller.
+func (s *Source) Close() error {
+	return nil
+}
+
+// refresh rereads the settings and reports how they changed since the last time.
+// There is nothing to report, i.e., ok is false, when ctx is canceled.
+func (s *Source) refresh(ctx context.Context) (u dynconf.SourceUpdate, ok bool) {
+	settings, err := s.fetch(ctx)
+	if ctx.Err() != nil {
+		return u, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		u.Err = err
+	} else {
+		u.Events = dynconf.DiffSettings(s.settings, settings)
+		if len(u.Events) != 0 {
+			s.settings = settings
+			s.rev++
+		}
+	}
+	u.Revision = s.rev
+
+	return u, true
+}
+
+// fetch returns all the settings of the path.
+func (s *Source) fetch(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, s.query, s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var setting, value string
+		if err = rows.Scan(&setting, &value); err != nil {
+			return nil, err
+		}
+		settings[setting] = value
+	}
+
+	return settings, rows.Err()
+}
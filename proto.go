@@ -0,0 +1,35 @@
+package dynconf
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// StructProto returns the protobuf message of the given setting
+// decoded from protojson, or base64-encoded binary wire format if the value isn't valid JSON.
+func (c *Config) StructProto(setting string, msg proto.Message) error {
+	return c.StructWith(setting, msg, unmarshalProto)
+}
+
+// unmarshalProto decodes protojson or base64-encoded binary data into out which must be a proto.Message.
+func unmarshalProto(data []byte, out interface{}) error {
+	msg, ok := out.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T is not a proto.Message", out)
+	}
+	if json.Valid(data) {
+		return protojson.Unmarshal(data, msg)
+	}
+
+	b := make([]byte, base64.StdEncoding.DecodedLen(len(data)))
+	n, err := base64.StdEncoding.Decode(b, data)
+	if err != nil {
+		return fmt.Errorf("value is neither JSON nor base64: %w", err)
+	}
+
+	return proto.Unmarshal(b[:n], msg)
+}
@@ -0,0 +1,74 @@
+package dynconf
+
+import (
+	"encoding/base64"
+	"os"
+	"testing"
+
+	"github.com/go-kit/log"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/sourcecontextpb"
+)
+
+func TestConfigStructProto(t *testing.T) {
+	want := &sourcecontextpb.SourceContext{FileName: "curiosity.proto"}
+	wire, err := proto.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		in      interface{}
+		wantErr bool
+	}{
+		"protojson": {
+			in: `{"fileName": "curiosity.proto"}`,
+		},
+		"binary": {
+			in: base64.StdEncoding.EncodeToString(wire),
+		},
+		"unknown field": {
+			in:      `{"name": "curiosity.proto"}`,
+			wantErr: true,
+		},
+		"malformed": {
+			in:      `{"fileName":`,
+			wantErr: true,
+		},
+		"bytes": {
+			in:      wire,
+			wantErr: true,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("source", tc.in)
+			var got sourcecontextpb.SourceContext
+			err := c.StructProto("source", &got)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !proto.Equal(want, &got) {
+				t.Errorf("expected %v got %v", want, &got)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package dynconf
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// parseRate parses the rate such as "100/s", "5000/m", "1/10s", or "inf" into events per second.
+// A rate without a unit is per second.
+func parseRate(s string) (rate.Limit, error) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "inf") {
+		return rate.Inf, nil
+	}
+
+	num, unit := s, "s"
+	if i := strings.IndexByte(s, '/'); i != -1 {
+		num, unit = s[:i], s[i+1:]
+	}
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, errors.New("rate must be a non-negative number")
+	}
+	// The unit is a duration such as 10s, or a duration unit such as s meaning 1s.
+	if unit != "" && (unit[0] < '0' || unit[0] > '9') {
+		unit = "1" + unit
+	}
+	per, err := time.ParseDuration(unit)
+	if err != nil {
+		return 0, err
+	}
+	if per <= 0 {
+		return 0, errors.New("rate interval must be positive")
+	}
+
+	return rate.Limit(n / per.Seconds()), nil
+}
+
+// Rate returns the rate limit of the given setting in events per second, e.g., 100 for "6000/m",
+// or defaultValue if it wasn't found or parsing failed.
+// The value is a number of events per duration unit (ns, us, ms, s, m, h) or per duration, e.g., "1/10s",
+// a number without a unit is per second, and "inf" means no limit.
+func (c *Config) Rate(setting string, defaultValue rate.Limit) rate.Limit {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	r, err := parseRate(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid rate setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return r
+}
@@ -0,0 +1,89 @@
+package dynconf
+
+import (
+	"math"
+	"os"
+	"testing"
+
+	"github.com/go-kit/log"
+	"golang.org/x/time/rate"
+)
+
+func TestConfigRate(t *testing.T) {
+	const defaultRate rate.Limit = 10
+
+	tests := map[string]struct {
+		in   interface{}
+		want rate.Limit
+	}{
+		"per second": {
+			in:   "100/s",
+			want: 100,
+		},
+		"per minute": {
+			in:   "6000/m",
+			want: 100,
+		},
+		"per duration": {
+			in:   "1/10s",
+			want: 0.1,
+		},
+		"per millisecond": {
+			in:   "1/ms",
+			want: 1000,
+		},
+		"without unit": {
+			in:   "50",
+			want: 50,
+		},
+		"inf": {
+			in:   "Inf",
+			want: rate.Inf,
+		},
+		"zero": {
+			in:   "0/s",
+			want: 0,
+		},
+		"negative": {
+			in:   "-1/s",
+			want: defaultRate,
+		},
+		"unknown unit": {
+			in:   "100/day",
+			want: defaultRate,
+		},
+		"empty unit": {
+			in:   "100/",
+			want: defaultRate,
+		},
+		"zero interval": {
+			in:   "100/0s",
+			want: defaultRate,
+		},
+		"int": {
+			in:   100,
+			want: defaultRate,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("rate_limit", tc.in)
+			got := c.Rate("rate_limit", defaultRate)
+			if tc.want != got && math.Abs(float64(tc.want-got)) > 1e-9 {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+// Package redissource provides a dynconf.Source backed by Redis.
+package redissource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pooyakn/dynconf"
+)
+
+const (
+	// batchSize is the number of keys scanned and fetched at once.
+	batchSize = 100
+	// retryDelay is how long Watch waits before resubscribing after an error.
+	retryDelay = time.Second
+)
+
+// Source is a dynconf.Source which keeps the settings either as Redis string keys under a prefix or
+// as fields of a Redis hash. The settings' changes are tracked with keyspace notifications,
+// so Redis must be configured to publish them, e.g., notify-keyspace-events KA.
+//
+// Redis has no revisions, so the Source counts the updates instead.
+// The notifications aren't delivered while the connection is lost,
+// hence all the settings are reloaded on every (re)subscription.
+type Source struct {
+	client *redis.Client
+	// prefix is the prefix of the keys to use as the settings.
+	prefix string
+	// hash is the key of the hash to use as the settings.
+	hash string
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// settings are the last known settings used to detect the changes.
+	settings map[string]string
+	// rev is the number of updates.
+	rev int64
+}
+
+// New returns a Source where the settings are stored as string keys under the prefix.
+// For example, if the prefix is /configs/curiosity/, then velocity setting is stored in /configs/curiosity/velocity key.
+func New(client *redis.Client, prefix string) *Source {
+	return &Source{
+		client:   client,
+		prefix:   prefix,
+		settings: make(map[string]string),
+	}
+}
+
+// NewHash returns a Source where the settings are stored as fields of the hash.
+func NewHash(client *redis.Client, key string) *Source {
+	return &Source{
+		client:   client,
+		hash:     key,
+		settings: make(map[string]string),
+	}
+}
+
+// Load fetches all the settings from Redis.
+func (s *Source) Load(ctx context.Context) (map[string]string, int64, error) {
+	settings, err := s.fetch(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = make(map[string]string, len(settings))
+	for k, v := range settings {
+		s.settings[k] = v
+	}
+	s.rev++
+
+	return settings, s.rev, nil
+}
+
+// Watch subscribes to the keyspace notifications of the settings' keys
+// and reports the changes until ctx is canceled.
+func (s *Source) Watch(ctx context.Context) <-chan dynconf.SourceUpdate {
+	out := make(chan dynconf.SourceUpdate)
+	go func() {
+		defer close(out)
+
+		channel := fmt.Sprintf("__keyspace@%d__:", s.client.Options().DB)
+		var pubsub *redis.PubSub
+		if s.hash != "" {
+			pubsub = s.client.Subscribe(ctx, channel+s.hash)
+		} else {
+			pubsub = s.client.PSubscribe(ctx, channel+escape(s.prefix)+"*")
+		}
+		// Closing the subscription interrupts the blocking Receive.
+		go func() {
+			<-ctx.Done()
+			pubsub.Close()
+		}()
+
+		for {
+			msg, err := pubsub.Receive(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+
+			var u dynconf.SourceUpdate
+			switch m := msg.(type) {
+			case *redis.Subscription:
+				// The notifications might have been missed while the connection was lost.
+				u, err = s.refresh(ctx, "")
+			case *redis.Message:
+				u, err = s.refresh(ctx, strings.TrimPrefix(m.Channel, channel))
+				if err == nil && len(u.Events) == 0 {
+					continue
+				}
+			default:
+				if err == nil {
+					continue
+				}
+			}
+			if err != nil {
+				u = dynconf.SourceUpdate{Err: err}
+			}
+
+			select {
+			case out <- u:
+			case <-ctx.Done():
+				return
+			}
+
+			if err != nil {
+				select {
+				case <-time.After(retryDelay):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// Ping pings the Redis server.
+func (s *Source) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// Close closes the Redis client.
+func (s *Source) Close() error {
+	return s.client.Close()
+}
+
+// refresh fetches the setting stored in the key, or all the settings if key is empty,
+// and reports how they changed since the last time.
+func (s *Source) refresh(ctx context.Context, key string) (dynconf.SourceUpdate, error) {
+	var (
+		fresh   map[string]string
+		setting string
+		err     error
+	)
+	if key == "" || s.hash != "" {
+		fresh, err = s.fetch(ctx)
+	} else {
+		setting = strings.TrimPrefix(key, s.prefix)
+		fresh = make(map[string]string, 1)
+		var v string
+		v, err = s.client.Get(ctx, key).Result()
+		if err == nil {
+			fresh[setting] = v
+		} else if errors.Is(err, redis.Nil) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return dynconf.SourceUpdate{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var u dynconf.SourceUpdate
+	for k, v := range fresh {
+		if old, ok := s.settings[k]; !ok || old != v {
+			s.settings[k] = v
+			u.Events = append(u.Events, dynconf.SourceEvent{Setting: k, Value: v})
+		}
+	}
+	for k := range s.settings {
+		if _, ok := fresh[k]; ok || (setting != "" && k != setting) {
+			continue
+		}
+		delete(s.settings, k)
+		u.Events = append(u.Events, dynconf.SourceEvent{Setting: k, Deleted: true})
+	}
+	if len(u.Events) != 0 {
+		s.rev++
+	}
+	u.Revision = s.rev
+
+	return u, nil
+}
+
+// fetch returns all the settings stored in Redis.
+func (s *Source) fetch(ctx context.Context) (map[string]string, error) {
+	if s.hash != "" {
+		return s.client.HGetAll(ctx, s.hash).Result()
+	}
+
+	var keys []string
+	iter := s.client.Scan(ctx, 0, escape(s.prefix)+"*", batchSize).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	settings := make(map[string]string, len(keys))
+	for i := 0; i < len(keys); i += batchSize {
+		batch := keys[i:]
+		if len(batch) > batchSize {
+			batch = batch[:batchSize]
+		}
+		values, err := s.client.MGet(ctx, batch...).Result()
+		if err != nil {
+			return nil, err
+		}
+		for j, v := range values {
+			// The keys deleted after the scan are nil.
+			if v, ok := v.(string); ok {
+				settings[batch[j][len(s.prefix):]] = v
+			}
+		}
+	}
+
+	return settings, nil
+}
+
+// escape escapes the glob-style pattern's special characters in s.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
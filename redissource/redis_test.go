@@ -0,0 +1,100 @@
+package redissource
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-redis/redis/v8"
+	"github.com/pooyakn/dynconf"
+)
+
+// newRedisClient returns a client connected to a local Redis with keyspace notifications enabled.
+// The test is skipped if Redis isn't running.
+func newRedisClient(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:6379"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		t.Skipf("redis is unreachable: %v", err)
+	}
+	if err := client.ConfigSet(ctx, "notify-keyspace-events", "KA").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestSource(t *testing.T) {
+	client := newRedisClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	prefix := "/configs/curiosity/"
+	if err := client.Set(ctx, prefix+"velocity", "5", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.Del(context.Background(), prefix+"velocity", prefix+"name")
+	})
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := dynconf.New(prefix, dynconf.WithLogger(logger), dynconf.WithSource(New(client, prefix)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if err = c.Ready(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Integer("velocity", 0); got != 5 {
+		t.Fatalf("expected velocity 5 got %d", got)
+	}
+
+	// Wait for the subscription to avoid missing the notifications.
+	if err = c.WaitConnected(ctx); err != nil {
+		t.Fatal(err)
+	}
+	client.Set(ctx, prefix+"name", "curiosity", 0)
+	client.Del(ctx, prefix+"velocity")
+	err = c.AwaitFunc(ctx, func(settings map[string]string) bool {
+		_, ok := settings["velocity"]
+		return settings["name"] == "curiosity" && !ok
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := map[string]struct {
+		in   string
+		want string
+	}{
+		"plain": {
+			in:   "/configs/curiosity/",
+			want: "/configs/curiosity/",
+		},
+		"glob": {
+			in:   "/configs/*/[a]?\\",
+			want: "/configs/\\*/\\[a\\]\\?\\\\",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := escape(tc.in); got != tc.want {
+				t.Errorf("expected %q got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package dynconf
+
+import "regexp"
+
+// regexpEntry is a compiled pattern cached along with the value it was compiled from.
+type regexpEntry struct {
+	raw string
+	re  *regexp.Regexp
+}
+
+// Regexp returns the regular expression compiled from the given setting,
+// or defaultValue if it wasn't found or compilation failed.
+// The compiled expression is cached until the setting's value changes.
+func (c *Config) Regexp(setting string, defaultValue *regexp.Regexp) *regexp.Regexp {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	if v, ok := c.regexps.Load(setting); ok {
+		if e, _ := v.(*regexpEntry); e != nil && e.raw == s {
+			return e.re
+		}
+	}
+
+	re, err := regexp.Compile(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid regexp setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+	c.regexps.Store(setting, &regexpEntry{raw: s, re: re})
+
+	return re
+}
@@ -0,0 +1,83 @@
+package dynconf
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/go-kit/log"
+)
+
+func TestConfigRegexp(t *testing.T) {
+	defaultPattern := regexp.MustCompile(`^$`)
+
+	tests := map[string]struct {
+		in   interface{}
+		want string
+	}{
+		"pattern": {
+			in:   `^rover-\d+$`,
+			want: `^rover-\d+$`,
+		},
+		"invalid pattern": {
+			in:   `^rover-(\d+$`,
+			want: defaultPattern.String(),
+		},
+		"int": {
+			in:   100,
+			want: defaultPattern.String(),
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		if got := c.Regexp("name_pattern", defaultPattern); got != defaultPattern {
+			t.Errorf("expected %s got %s", defaultPattern, got)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("name_pattern", tc.in)
+			if got := c.Regexp("name_pattern", defaultPattern).String(); tc.want != got {
+				t.Errorf("expected %s got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigRegexpCache(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.settings.Store("name_pattern", `^rover-\d+$`)
+	re := c.Regexp("name_pattern", nil)
+	if got := c.Regexp("name_pattern", nil); got != re {
+		t.Errorf("expected cached regexp")
+	}
+
+	// The pattern was changed, so the cached regexp must not be used.
+	c.settings.Store("name_pattern", `^lander-\d+$`)
+	got := c.Regexp("name_pattern", nil)
+	if got == re || !got.MatchString("lander-1") {
+		t.Errorf("expected recompiled regexp got %s", got)
+	}
+}
@@ -0,0 +1,155 @@
+package dynconf
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MultiError holds the errors of all the settings that didn't meet the requirements.
+type MultiError []error
+
+func (e MultiError) Error() string {
+	ss := make([]string, len(e))
+	for i, err := range e {
+		ss[i] = err.Error()
+	}
+
+	return strings.Join(ss, "; ")
+}
+
+// RequireEqualLength checks that the array values of both settings have the same number of elements,
+// e.g., sensor_ids and sensor_weights settings that must be paired.
+func (c *Config) RequireEqualLength(settingA, settingB, delimiter string) error {
+	a, err := c.StringRequired(settingA)
+	if err != nil {
+		return err
+	}
+	b, err := c.StringRequired(settingB)
+	if err != nil {
+		return err
+	}
+
+	lenA, lenB := arrayLen(a, delimiter), arrayLen(b, delimiter)
+	if lenA != lenB {
+		return fmt.Errorf("dynconf settings length mismatch: %s has %d elements, %s has %d elements", settingA, lenA, settingB, lenB)
+	}
+
+	return nil
+}
+
+// Requirements checks multiple required settings at once,
+// so all the missing or invalid settings are reported together.
+// It is created with Config.Require, for example:
+//
+//	r := c.Require().String("name").Int("velocity").Bool("is_camera_enabled")
+//	if err := r.Err(); err != nil {
+//		return err
+//	}
+//	velocity := r.GetInt("velocity")
+type Requirements struct {
+	c      *Config
+	values map[string]interface{}
+	errs   MultiError
+}
+
+// Require returns Requirements to check multiple required settings at once.
+func (c *Config) Require() *Requirements {
+	return &Requirements{
+		c:      c,
+		values: make(map[string]interface{}),
+	}
+}
+
+// add records the setting value or its error.
+func (r *Requirements) add(setting string, v interface{}, err error) *Requirements {
+	if err != nil {
+		r.errs = append(r.errs, err)
+		return r
+	}
+
+	r.values[setting] = v
+	return r
+}
+
+// String requires the string setting.
+func (r *Requirements) String(setting string) *Requirements {
+	v, err := r.c.StringRequired(setting)
+	return r.add(setting, v, err)
+}
+
+// Int requires the integer setting.
+func (r *Requirements) Int(setting string) *Requirements {
+	v, err := r.c.IntegerRequired(setting)
+	return r.add(setting, v, err)
+}
+
+// Int64 requires the int64 setting.
+func (r *Requirements) Int64(setting string) *Requirements {
+	v, err := r.c.Int64Required(setting)
+	return r.add(setting, v, err)
+}
+
+// Bool requires the boolean setting.
+func (r *Requirements) Bool(setting string) *Requirements {
+	v, err := r.c.BooleanRequired(setting)
+	return r.add(setting, v, err)
+}
+
+// Float requires the float setting.
+func (r *Requirements) Float(setting string) *Requirements {
+	v, err := r.c.FloatRequired(setting)
+	return r.add(setting, v, err)
+}
+
+// Duration requires the duration setting.
+func (r *Requirements) Duration(setting string) *Requirements {
+	v, err := r.c.DurationRequired(setting)
+	return r.add(setting, v, err)
+}
+
+// Err returns MultiError listing all the settings that didn't meet the requirements,
+// or nil if all of them are valid.
+func (r *Requirements) Err() error {
+	if len(r.errs) == 0 {
+		return nil
+	}
+
+	return r.errs
+}
+
+// GetString returns the value of the required string setting.
+func (r *Requirements) GetString(setting string) string {
+	v, _ := r.values[setting].(string)
+	return v
+}
+
+// GetInt returns the value of the required integer setting.
+func (r *Requirements) GetInt(setting string) int {
+	v, _ := r.values[setting].(int)
+	return v
+}
+
+// GetInt64 returns the value of the required int64 setting.
+func (r *Requirements) GetInt64(setting string) int64 {
+	v, _ := r.values[setting].(int64)
+	return v
+}
+
+// GetBool returns the value of the required boolean setting.
+func (r *Requirements) GetBool(setting string) bool {
+	v, _ := r.values[setting].(bool)
+	return v
+}
+
+// GetFloat returns the value of the required float setting.
+func (r *Requirements) GetFloat(setting string) float64 {
+	v, _ := r.values[setting].(float64)
+	return v
+}
+
+// GetDuration returns the value of the required duration setting.
+func (r *Requirements) GetDuration(setting string) time.Duration {
+	v, _ := r.values[setting].(time.Duration)
+	return v
+}
@@ -0,0 +1,131 @@
+package dynconf
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+func TestRequire(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.settings.Store("name", "curiosity")
+	c.settings.Store("velocity", "10")
+	c.settings.Store("timeout", "5s")
+
+	r := c.Require().
+		String("name").
+		Int("velocity").
+		Duration("timeout")
+	if err = r.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := r.GetString("name"); got != "curiosity" {
+		t.Errorf("expected name %q got %q", "curiosity", got)
+	}
+	if got := r.GetInt("velocity"); got != 10 {
+		t.Errorf("expected velocity %d got %d", 10, got)
+	}
+	if got := r.GetDuration("timeout"); got != 5*time.Second {
+		t.Errorf("expected timeout %s got %s", 5*time.Second, got)
+	}
+}
+
+func TestRequireMultipleErrors(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.settings.Store("velocity", "fast")
+
+	err = c.Require().
+		String("name").
+		Int("velocity").
+		Bool("is_camera_enabled").
+		Err()
+
+	var merr MultiError
+	if !errors.As(err, &merr) {
+		t.Fatalf("expected MultiError got %v", err)
+	}
+	if len(merr) != 3 {
+		t.Errorf("expected 3 errors got %d: %v", len(merr), merr)
+	}
+	for _, setting := range []string{"name", "velocity", "is_camera_enabled"} {
+		if !strings.Contains(err.Error(), setting) {
+			t.Errorf("expected %s to be reported: %v", setting, err)
+		}
+	}
+}
+
+func TestRequireEqualLength(t *testing.T) {
+	tests := map[string]struct {
+		ids     interface{}
+		weights interface{}
+		wantErr bool
+	}{
+		"equal": {
+			ids:     "a,b,c",
+			weights: "0.5,0.3,0.2",
+		},
+		"mismatched": {
+			ids:     "a,b,c",
+			weights: "0.5,0.5",
+			wantErr: true,
+		},
+		"missing": {
+			ids:     "a,b,c",
+			wantErr: true,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Delete("sensor_weights")
+			c.settings.Store("sensor_ids", tc.ids)
+			if tc.weights != nil {
+				c.settings.Store("sensor_weights", tc.weights)
+			}
+
+			err := c.RequireEqualLength("sensor_ids", "sensor_weights", ",")
+			if tc.wantErr && err == nil {
+				t.Errorf("expected error")
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package dynconf
+
+import (
+	"context"
+	"strings"
+)
+
+// ResolvedString returns the string value of the given setting,
+// or defaultValue if it wasn't found or the resolution failed.
+// If the value is a reference with a scheme registered by WithResolver, e.g., "secretref://aws/prod/db-password",
+// the resolved value is returned instead. The resolved values are cached by their references.
+// Values without a registered scheme are returned as is.
+func (c *Config) ResolvedString(ctx context.Context, setting, defaultValue string) string {
+	s := c.String(setting, defaultValue)
+
+	i := strings.Index(s, "://")
+	if i < 0 {
+		return s
+	}
+	resolve, ok := c.resolvers[s[:i]]
+	if !ok {
+		return s
+	}
+
+	if v, ok := c.resolved.Load(s); ok {
+		r, _ := v.(string)
+		return r
+	}
+
+	r, err := resolve(ctx, s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf failed to resolve setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+	c.resolved.Store(s, r)
+
+	return r
+}
@@ -0,0 +1,73 @@
+package dynconf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/go-kit/log"
+)
+
+func TestResolvedString(t *testing.T) {
+	var calls int
+	resolve := func(ctx context.Context, ref string) (string, error) {
+		calls++
+		if ref == "secretref://aws/prod/db-password" {
+			return "s3cr3t", nil
+		}
+		return "", errors.New("secret not found")
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithResolver("secretref", resolve))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	tests := map[string]struct {
+		in   string
+		want string
+	}{
+		"plain": {
+			in:   "alice",
+			want: "alice",
+		},
+		"secret": {
+			in:   "secretref://aws/prod/db-password",
+			want: "s3cr3t",
+		},
+		"unknown secret": {
+			in:   "secretref://aws/prod/unknown",
+			want: "default",
+		},
+		"unknown scheme": {
+			in:   "https://example.com",
+			want: "https://example.com",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("db_password", tc.in)
+			got := c.ResolvedString(context.Background(), "db_password", "default")
+			if tc.want != got {
+				t.Errorf("expected %q got %q", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		c.settings.Store("db_password", "secretref://aws/prod/db-password")
+		calls = 0
+		c.ResolvedString(context.Background(), "db_password", "default")
+		if calls != 0 {
+			t.Errorf("expected cached value got %d resolver calls", calls)
+		}
+	})
+}
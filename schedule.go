@@ -0,0 +1,159 @@
+package dynconf
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Clock is a time of day, e.g., the start of a maintenance window.
+type Clock struct {
+	Hour, Minute, Second int
+}
+
+// String returns the time of day formatted as HH:MM:SS.
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d:%02d", c.Hour, c.Minute, c.Second)
+}
+
+// Duration returns the time elapsed since midnight.
+func (c Clock) Duration() time.Duration {
+	return time.Duration(c.Hour)*time.Hour + time.Duration(c.Minute)*time.Minute + time.Duration(c.Second)*time.Second
+}
+
+// On returns the time of day on the date of t in t's location.
+func (c Clock) On(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, c.Hour, c.Minute, c.Second, 0, t.Location())
+}
+
+// parseWeekday parses the case-insensitive name of the day, e.g., "Monday" or "mon".
+func parseWeekday(s string) (time.Weekday, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || s == name[:3] {
+			return d, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown weekday %q", s)
+}
+
+// parseClock parses the time of day formatted as HH:MM or HH:MM:SS in 24-hour format,
+// the hour can have a single digit, e.g., 9:30.
+func parseClock(s string) (Clock, error) {
+	layout := "15:04:05"
+	if strings.Count(s, ":") == 1 {
+		layout = "15:04"
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return Clock{}, err
+	}
+
+	return Clock{Hour: t.Hour(), Minute: t.Minute(), Second: t.Second()}, nil
+}
+
+// TimeOfDay returns the time of day value of the given setting formatted as HH:MM or HH:MM:SS,
+// or defaultValue if it wasn't found or parsing failed.
+func (c *Config) TimeOfDay(setting string, defaultValue Clock) Clock {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	clock, err := parseClock(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid time of day setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return clock
+}
+
+// Weekday returns the day of the week of the given setting, e.g., "mon" or "Monday",
+// or defaultValue if it wasn't found or parsing failed.
+func (c *Config) Weekday(setting string, defaultValue time.Weekday) time.Weekday {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	d, err := parseWeekday(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid weekday setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return d
+}
+
+// WeekdayArray returns the days of the week of the given setting split by delimiter, e.g., "mon,tue".
+// Invalid days are skipped. It returns nil if the setting wasn't found or its value is empty.
+func (c *Config) WeekdayArray(setting string, delimiter string) []time.Weekday {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return nil
+	}
+	if s == "" {
+		return nil
+	}
+
+	var ds []time.Weekday
+	for _, e := range strings.Split(s, delimiter) {
+		d, err := parseWeekday(e)
+		if err != nil {
+			c.logger.Log("msg", "dynconf invalid weekday", "path", c.path, "setting", setting, "value", e, "err", err)
+			continue
+		}
+		ds = append(ds, d)
+	}
+
+	return ds
+}
+
+// Location returns the time zone of the given setting, e.g., "Europe/Berlin",
+// or defaultValue if it wasn't found or the zone is unknown.
+func (c *Config) Location(setting string, defaultValue *time.Location) *time.Location {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid location setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+
+	return loc
+}
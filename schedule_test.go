@@ -0,0 +1,227 @@
+package dynconf
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+func TestConfigTimeOfDay(t *testing.T) {
+	defaultWindow := Clock{Hour: 3}
+
+	tests := map[string]struct {
+		in   interface{}
+		want Clock
+	}{
+		"hours and minutes": {
+			in:   "23:30",
+			want: Clock{Hour: 23, Minute: 30},
+		},
+		"with seconds": {
+			in:   "04:05:06",
+			want: Clock{Hour: 4, Minute: 5, Second: 6},
+		},
+		"single digit hour": {
+			in:   "4:05",
+			want: Clock{Hour: 4, Minute: 5},
+		},
+		"single digit hour with seconds": {
+			in:   "9:30:15",
+			want: Clock{Hour: 9, Minute: 30, Second: 15},
+		},
+		"single digit minute": {
+			in:   "09:5",
+			want: defaultWindow,
+		},
+		"out of range": {
+			in:   "24:00",
+			want: defaultWindow,
+		},
+		"12-hour format": {
+			in:   "11:30PM",
+			want: defaultWindow,
+		},
+		"int": {
+			in:   2330,
+			want: defaultWindow,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("maintenance_start", tc.in)
+			if got := c.TimeOfDay("maintenance_start", defaultWindow); tc.want != got {
+				t.Errorf("expected %s got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestClock(t *testing.T) {
+	clock := Clock{Hour: 23, Minute: 30, Second: 15}
+	if got, want := clock.String(), "23:30:15"; got != want {
+		t.Errorf("expected %s got %s", want, got)
+	}
+	if got, want := clock.Duration(), 23*time.Hour+30*time.Minute+15*time.Second; got != want {
+		t.Errorf("expected %s got %s", want, got)
+	}
+
+	day := time.Date(2021, time.March, 10, 8, 0, 0, 0, time.UTC)
+	if got, want := clock.On(day), time.Date(2021, time.March, 10, 23, 30, 15, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("expected %s got %s", want, got)
+	}
+}
+
+func TestConfigWeekday(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		want time.Weekday
+	}{
+		"abbreviation": {
+			in:   "mon",
+			want: time.Monday,
+		},
+		"full name": {
+			in:   "Saturday",
+			want: time.Saturday,
+		},
+		"uppercase": {
+			in:   "TUE",
+			want: time.Tuesday,
+		},
+		"unknown": {
+			in:   "mo",
+			want: time.Sunday,
+		},
+		"int": {
+			in:   1,
+			want: time.Sunday,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("release_day", tc.in)
+			if got := c.Weekday("release_day", time.Sunday); tc.want != got {
+				t.Errorf("expected %s got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigWeekdayArray(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		want []time.Weekday
+	}{
+		"days": {
+			in:   "mon, Tue,friday",
+			want: []time.Weekday{time.Monday, time.Tuesday, time.Friday},
+		},
+		"invalid day": {
+			in:   "mon,someday",
+			want: []time.Weekday{time.Monday},
+		},
+		"empty": {
+			in: "",
+		},
+		"int": {
+			in: 1,
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("working_days", tc.in)
+			got := c.WeekdayArray("working_days", ",")
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigLocation(t *testing.T) {
+	tests := map[string]struct {
+		in   interface{}
+		want string
+	}{
+		"zone": {
+			in:   "Europe/Berlin",
+			want: "Europe/Berlin",
+		},
+		"UTC": {
+			in:   "UTC",
+			want: "UTC",
+		},
+		"unknown zone": {
+			in:   "Mars/Gale_Crater",
+			want: "America/New_York",
+		},
+		"int": {
+			in:   1,
+			want: "America/New_York",
+		},
+	}
+
+	defaultLocation, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database is unavailable:", err)
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("time_zone", tc.in)
+			if got := c.Location("time_zone", defaultLocation).String(); tc.want != got {
+				t.Errorf("expected %s got %s", tc.want, got)
+			}
+		})
+	}
+}
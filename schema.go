@@ -0,0 +1,144 @@
+package dynconf
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Schema declares the types and constraints of the settings, see WithSchema.
+// The settings which aren't declared can have any value.
+type Schema struct {
+	rules map[string]func(value string) error
+}
+
+// NewSchema returns an empty Schema, e.g.,
+//
+//	NewSchema().
+//		IntegerRange("velocity", 0, 100).
+//		Boolean("is_camera_enabled").
+//		String("mode", "drive", "park")
+func NewSchema() *Schema {
+	return &Schema{rules: make(map[string]func(string) error)}
+}
+
+// Func declares that the setting's value must pass the validate function.
+func (s *Schema) Func(setting string, validate func(value string) error) *Schema {
+	s.rules[setting] = validate
+	return s
+}
+
+// String declares a string setting which must be one of the allowed values if any are given.
+func (s *Schema) String(setting string, allowed ...string) *Schema {
+	return s.Func(setting, func(value string) error {
+		if len(allowed) == 0 {
+			return nil
+		}
+		for _, a := range allowed {
+			if value == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not one of %q", value, allowed)
+	})
+}
+
+// Pattern declares a string setting which must match the regular expression.
+func (s *Schema) Pattern(setting string, re *regexp.Regexp) *Schema {
+	return s.Func(setting, func(value string) error {
+		if !re.MatchString(value) {
+			return fmt.Errorf("%q doesn't match %s", value, re)
+		}
+		return nil
+	})
+}
+
+// Boolean declares a boolean setting.
+func (s *Schema) Boolean(setting string) *Schema {
+	return s.Func(setting, func(value string) error {
+		_, err := strconv.ParseBool(value)
+		return err
+	})
+}
+
+// Integer declares an integer setting.
+func (s *Schema) Integer(setting string) *Schema {
+	return s.Func(setting, func(value string) error {
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err
+	})
+}
+
+// IntegerRange declares an integer setting within [min, max].
+func (s *Schema) IntegerRange(setting string, min, max int64) *Schema {
+	return s.Func(setting, func(value string) error {
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		if i < min || i > max {
+			return fmt.Errorf("%d is out of range [%d, %d]", i, min, max)
+		}
+		return nil
+	})
+}
+
+// Float declares a float setting.
+func (s *Schema) Float(setting string) *Schema {
+	return s.Func(setting, func(value string) error {
+		_, err := strconv.ParseFloat(value, 64)
+		return err
+	})
+}
+
+// FloatRange declares a float setting within [min, max].
+func (s *Schema) FloatRange(setting string, min, max float64) *Schema {
+	return s.Func(setting, func(value string) error {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		if f < min || f > max {
+			return fmt.Errorf("%g is out of range [%g, %g]", f, min, max)
+		}
+		return nil
+	})
+}
+
+// Duration declares a duration setting.
+func (s *Schema) Duration(setting string) *Schema {
+	return s.Func(setting, func(value string) error {
+		_, err := time.ParseDuration(value)
+		return err
+	})
+}
+
+// Validate checks the value of the setting against its declaration.
+func (s *Schema) Validate(setting, value string) error {
+	validate, ok := s.rules[setting]
+	if !ok {
+		return nil
+	}
+
+	return validate(value)
+}
+
+// valid reports whether the setting's value satisfies the schema set with WithSchema,
+// the invalid values are logged and reported to the onInvalid callback.
+func (c *Config) valid(setting, value string) bool {
+	if c.schema == nil {
+		return true
+	}
+	err := c.schema.Validate(setting, value)
+	if err == nil {
+		return true
+	}
+
+	c.logger.Log("msg", "dynconf rejected invalid setting", "path", c.path, "setting", setting, "value", value, "err", err)
+	if c.onInvalid != nil {
+		c.protect("onInvalid", func() { c.onInvalid(setting, value, err) })
+	}
+
+	return false
+}
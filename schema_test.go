@@ -0,0 +1,104 @@
+package dynconf
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/google/go-cmp/cmp"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	s := NewSchema().
+		IntegerRange("velocity", 0, 100).
+		Integer("cameras").
+		FloatRange("ratio", 0, 1).
+		Float("speed").
+		Boolean("is_camera_enabled").
+		Duration("timeout").
+		String("mode", "drive", "park").
+		String("name").
+		Pattern("mission", regexp.MustCompile(`^[a-z]+$`))
+
+	tests := map[string]struct {
+		setting string
+		value   string
+		wantErr bool
+	}{
+		"integer":              {setting: "velocity", value: "5"},
+		"integer out of range": {setting: "velocity", value: "101", wantErr: true},
+		"invalid integer":      {setting: "cameras", value: "1O", wantErr: true},
+		"float":                {setting: "ratio", value: "0.5"},
+		"float out of range":   {setting: "ratio", value: "1.5", wantErr: true},
+		"invalid float":        {setting: "speed", value: "fast", wantErr: true},
+		"boolean":              {setting: "is_camera_enabled", value: "true"},
+		"invalid boolean":      {setting: "is_camera_enabled", value: "yes", wantErr: true},
+		"duration":             {setting: "timeout", value: "5s"},
+		"invalid duration":     {setting: "timeout", value: "5", wantErr: true},
+		"enum":                 {setting: "mode", value: "park"},
+		"invalid enum":         {setting: "mode", value: "fly", wantErr: true},
+		"any string":           {setting: "name", value: "curiosity"},
+		"pattern":              {setting: "mission", value: "mars"},
+		"invalid pattern":      {setting: "mission", value: "Mars 2020", wantErr: true},
+		"undeclared":           {setting: "color", value: "red"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := s.Validate(tc.setting, tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error %t got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWithSchema(t *testing.T) {
+	var rejected []string
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New(
+		"/configs/curiosity/",
+		WithLogger(logger),
+		WithSchema(NewSchema().IntegerRange("velocity", 0, 100), func(setting, value string, err error) {
+			rejected = append(rejected, setting+"="+value)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	var changes []Change
+	c.AddListener(func(cc []Change) { changes = append(changes, cc...) })
+
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+		putEvent("/configs/curiosity/velocity", "1O", 2),
+		putEvent("/configs/curiosity/name", "curiosity", 2),
+	}, 2))
+	if c.Has("velocity") {
+		t.Error("expected invalid velocity to be rejected")
+	}
+
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/velocity", "5", 3)}, 3))
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/velocity", "500", 4)}, 4))
+	if got := c.Integer("velocity", 0); got != 5 {
+		t.Errorf("expected the last valid velocity 5 got %d", got)
+	}
+
+	if diff := cmp.Diff([]string{"velocity=1O", "velocity=500"}, rejected); diff != "" {
+		t.Error(diff)
+	}
+	want := []Change{
+		{Setting: "name", New: "curiosity"},
+		{Setting: "velocity", New: "5"},
+	}
+	if diff := cmp.Diff(want, changes); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,224 @@
+package dynconf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc/connectivity"
+)
+
+// errNoEtcd is returned by the operations that require etcd when Config is backed by another Source.
+var errNoEtcd = errors.New("dynconf etcd client is not set")
+
+// Source is a store the settings are loaded from, e.g., etcd.
+// Config loads all the settings from the source once and then keeps them up to date by watching it.
+type Source interface {
+	// Load returns all the settings by their names and the revision they're at.
+	// The revision is an increasing number identifying the state of the store,
+	// sources without revisions can return zero.
+	Load(ctx context.Context) (settings map[string]string, rev int64, err error)
+	// Watch returns a channel of the settings' changes.
+	// The channel should be closed when ctx is canceled.
+	Watch(ctx context.Context) <-chan SourceUpdate
+	// Close releases the resources held by the source.
+	Close() error
+}
+
+// Pinger is implemented by the sources which can check that their store is reachable, see Config.Ping.
+// Unlike Load, Ping shouldn't affect the changes reported by Watch.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// SourceUpdate is a batch of the settings' changes reported by a Source.
+type SourceUpdate struct {
+	Events []SourceEvent
+	// Revision is the revision of the store after the changes.
+	Revision int64
+	// Err is set when the source failed to watch the store,
+	// it's used to report whether the store is reachable.
+	Err error
+
+	// raw holds the etcd events the update was made of, see WithOnRawEvent.
+	raw []*clientv3.Event
+}
+
+// SourceEvent is a change of a setting.
+type SourceEvent struct {
+	Setting string
+	Value   string
+	Deleted bool
+	// Revision is the revision of the store when the setting was changed, e.g., etcd mod revision.
+	// It can be zero if the source doesn't track it, then the update's revision is used.
+	Revision int64
+}
+
+// DiffSettings returns the events turning the settings from into the settings to, sorted by the settings' names.
+// It helps to implement the sources which can only fetch all the settings at once.
+func DiffSettings(from, to map[string]string) []SourceEvent {
+	var events []SourceEvent
+	for k, v := range to {
+		if old, ok := from[k]; !ok || old != v {
+			events = append(events, SourceEvent{Setting: k, Value: v})
+		}
+	}
+	for k := range from {
+		if _, ok := to[k]; !ok {
+			events = append(events, SourceEvent{Setting: k, Deleted: true})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Setting < events[j].Setting
+	})
+
+	return events
+}
+
+const (
+	// keepAliveTime is how often the default etcd client pings the server to check the connection,
+	// and keepAliveTimeout is how long it waits for the response before closing the connection.
+	keepAliveTime    = 30 * time.Second
+	keepAliveTimeout = 10 * time.Second
+)
+
+// etcdSource is the default Source which keeps the settings as etcd keys under the path.
+type etcdSource struct {
+	client *clientv3.Client
+	path   string
+}
+
+// NewEtcdSource returns the Source which keeps the settings as etcd keys under the path,
+// e.g., to combine it with other sources, see NewLayeredSource.
+// It's the default Source of Config.
+func NewEtcdSource(client *clientv3.Client, path string) Source {
+	return &etcdSource{client: client, path: path}
+}
+
+// Load fetches all the settings from etcd for the configured path.
+func (s *etcdSource) Load(ctx context.Context) (map[string]string, int64, error) {
+	r, err := s.client.Get(ctx, s.path, clientv3.WithPrefix())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// prefixLen is the length of the key prefix (path) in etcd to extract a setting name.
+	prefixLen := len(s.path)
+	settings := make(map[string]string, len(r.Kvs))
+	for _, kv := range r.Kvs {
+		settings[string(kv.Key)[prefixLen:]] = string(kv.Value)
+	}
+
+	return settings, r.Header.Revision, nil
+}
+
+// Watch watches for the settings' changes in etcd.
+// As long as the context has not been canceled,
+// it will retry on recoverable errors forever until reconnected.
+// The connection's state changes are reported as well, see watchConnection.
+func (s *etcdSource) Watch(ctx context.Context) <-chan SourceUpdate {
+	out := make(chan SourceUpdate)
+	send := func(u SourceUpdate) bool {
+		select {
+		case out <- u:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for r := range s.client.Watch(ctx, s.path, clientv3.WithPrefix(), clientv3.WithPrevKV()) {
+			u := newEtcdUpdate(s.path, r.Events, r.Header.Revision)
+			u.Err = r.Err()
+			if !send(u) {
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		s.watchConnection(ctx, send)
+	}()
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// watchConnection sends an update with an error when the client's connection fails,
+// and an empty update when it's ready again, since the watch itself doesn't report a dead connection.
+// The connecting and idle states are transitional, so they're not reported.
+func (s *etcdSource) watchConnection(ctx context.Context, send func(SourceUpdate) bool) {
+	conn := s.client.ActiveConnection()
+	if conn == nil {
+		// The client isn't connected by itself, e.g., it was created with clientv3.NewCtxClient.
+		return
+	}
+	state := conn.GetState()
+	for conn.WaitForStateChange(ctx, state) {
+		state = conn.GetState()
+
+		var u SourceUpdate
+		switch state {
+		case connectivity.Ready:
+		case connectivity.TransientFailure, connectivity.Shutdown:
+			u.Err = fmt.Errorf("dynconf etcd connection is %s", state)
+		default:
+			continue
+		}
+		if !send(u) {
+			return
+		}
+	}
+}
+
+// Ping fetches at most one key under the path.
+func (s *etcdSource) Ping(ctx context.Context) error {
+	_, err := s.client.Get(ctx, s.path, clientv3.WithPrefix(), clientv3.WithLimit(1), clientv3.WithKeysOnly())
+	return err
+}
+
+// Close closes the etcd client.
+func (s *etcdSource) Close() error {
+	return s.client.Close()
+}
+
+// newEtcdUpdate converts the etcd events of the keys under the path to the settings' changes.
+func newEtcdUpdate(path string, events []*clientv3.Event, rev int64) SourceUpdate {
+	u := SourceUpdate{
+		Events:   make([]SourceEvent, 0, len(events)),
+		Revision: rev,
+		raw:      events,
+	}
+	for _, e := range events {
+		u.Events = append(u.Events, SourceEvent{
+			Setting:  string(e.Kv.Key)[len(path):],
+			Value:    string(e.Kv.Value),
+			Deleted:  e.Type == clientv3.EventTypeDelete,
+			Revision: e.Kv.ModRevision,
+		})
+	}
+
+	return u
+}
+
+// noUpdates returns a channel which is closed when ctx is canceled, it's for the sources that never change.
+func noUpdates(ctx context.Context) <-chan SourceUpdate {
+	out := make(chan SourceUpdate)
+	go func() {
+		<-ctx.Done()
+		close(out)
+	}()
+
+	return out
+}
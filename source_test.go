@@ -0,0 +1,160 @@
+package dynconf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+// fakeSource is a Source whose changes are sent by tests.
+type fakeSource struct {
+	settings map[string]string
+	updates  chan SourceUpdate
+	closed   bool
+}
+
+func (s *fakeSource) Load(ctx context.Context) (map[string]string, int64, error) {
+	return s.settings, 1, nil
+}
+
+func (s *fakeSource) Watch(ctx context.Context) <-chan SourceUpdate {
+	return s.updates
+}
+
+func (s *fakeSource) Close() error {
+	s.closed = true
+	close(s.updates)
+	return nil
+}
+
+func TestWithSource(t *testing.T) {
+	src := &fakeSource{
+		settings: map[string]string{"velocity": "5"},
+		updates:  make(chan SourceUpdate),
+	}
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithSource(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err = c.Ready(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Integer("velocity", 0); got != 5 {
+		t.Errorf("expected velocity 5 got %d", got)
+	}
+	if err = c.Ping(ctx); err != nil {
+		t.Errorf("expected ping to report the loaded source got %v", err)
+	}
+
+	src.updates <- SourceUpdate{
+		Events: []SourceEvent{
+			{Setting: "velocity", Deleted: true},
+			{Setting: "name", Value: "curiosity"},
+		},
+		Revision: 2,
+	}
+	if err = c.AwaitFunc(ctx, func(settings map[string]string) bool { return settings["name"] == "curiosity" }); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Integer("velocity", 0); got != 0 {
+		t.Errorf("expected velocity to be deleted got %d", got)
+	}
+
+	if err = c.Set(ctx, "velocity", "10"); !errors.Is(err, errNoEtcd) {
+		t.Errorf("expected no etcd error got %v", err)
+	}
+	if _, err = c.Lag(ctx); !errors.Is(err, errNoEtcd) {
+		t.Errorf("expected no etcd error got %v", err)
+	}
+
+	if err = c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !src.closed {
+		t.Error("expected the source to be closed")
+	}
+}
+
+// failingSource is a Source which can't be loaded.
+type failingSource struct {
+	fakeSource
+	loads int32
+}
+
+func (s *failingSource) Load(ctx context.Context) (map[string]string, int64, error) {
+	atomic.AddInt32(&s.loads, 1)
+	return nil, 0, errors.New("connection refused")
+}
+
+func TestPingSource(t *testing.T) {
+	src := &failingSource{fakeSource: fakeSource{updates: make(chan SourceUpdate)}}
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithSource(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	// The source doesn't implement Pinger, so the last error is reported.
+	for c.Status().LastError == "" {
+		select {
+		case <-ctx.Done():
+			t.Fatal("expected the load to fail")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	if err = c.Ping(ctx); err == nil {
+		t.Error("expected ping error")
+	}
+	if got := atomic.LoadInt32(&src.loads); got != 1 {
+		t.Errorf("expected the source to be loaded once got %d", got)
+	}
+}
+
+func TestDiffSettings(t *testing.T) {
+	tests := map[string]struct {
+		from map[string]string
+		to   map[string]string
+		want []SourceEvent
+	}{
+		"nil": {},
+		"same": {
+			from: map[string]string{"velocity": "5"},
+			to:   map[string]string{"velocity": "5"},
+		},
+		"changed": {
+			from: map[string]string{"velocity": "5", "name": "curiosity"},
+			to:   map[string]string{"velocity": "10", "is_camera_enabled": "true"},
+			want: []SourceEvent{
+				{Setting: "is_camera_enabled", Value: "true"},
+				{Setting: "name", Deleted: true},
+				{Setting: "velocity", Value: "10"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := DiffSettings(tc.from, tc.to)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %v got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package dynconf
+
+import (
+	"context"
+	"fmt"
+)
+
+// staticSource is a Source whose settings never change.
+type staticSource struct {
+	settings map[string]string
+}
+
+// Load returns a copy of the settings.
+func (s *staticSource) Load(ctx context.Context) (map[string]string, int64, error) {
+	settings := make(map[string]string, len(s.settings))
+	for k, v := range s.settings {
+		settings[k] = v
+	}
+
+	return settings, 1, nil
+}
+
+// Watch returns a channel which is closed when ctx is canceled since there are no changes.
+func (s *staticSource) Watch(ctx context.Context) <-chan SourceUpdate {
+	return noUpdates(ctx)
+}
+
+// Ping does nothing since there is no store.
+func (s *staticSource) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close does nothing.
+func (s *staticSource) Close() error {
+	return nil
+}
+
+// NewStatic returns a Config holding the given settings with no network dependency,
+// e.g., for unit tests that shouldn't require a running etcd.
+// The Config is ready to use when it's returned.
+func NewStatic(settings map[string]string, options ...Option) (*Config, error) {
+	options = append(options, WithSource(&staticSource{settings: settings}))
+	c, err := New("", options...)
+	if err != nil {
+		return nil, err
+	}
+
+	// The settings are loaded right away, so it doesn't block.
+	if err = c.Ready(context.Background()); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("dynconf static settings not loaded: %w", err)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,47 @@
+package dynconf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/go-kit/log"
+)
+
+func TestNewStatic(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := NewStatic(map[string]string{
+		"velocity":          "5",
+		"is_camera_enabled": "true",
+	}, WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if got := c.Integer("velocity", 0); got != 5 {
+		t.Errorf("expected velocity 5 got %d", got)
+	}
+	if got := c.Boolean("is_camera_enabled", false); !got {
+		t.Error("expected camera to be enabled")
+	}
+	if err = c.Ping(context.Background()); err != nil {
+		t.Errorf("expected ping to succeed got %v", err)
+	}
+	if err = c.Set(context.Background(), "velocity", "10"); !errors.Is(err, errNoEtcd) {
+		t.Errorf("expected no etcd error got %v", err)
+	}
+}
+
+func TestNewStaticRequiredKeys(t *testing.T) {
+	_, err := NewStatic(map[string]string{"velocity": "5"}, WithRequireKeys("velocity", "name"))
+	want := "dynconf required settings not found: name"
+	if err == nil || err.Error() != want {
+		t.Errorf("expected %q got %v", want, err)
+	}
+}
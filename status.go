@@ -0,0 +1,68 @@
+package dynconf
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ConfigStatus describes the state of a Config, e.g., for a health check endpoint.
+type ConfigStatus struct {
+	// Ready indicates that the settings were loaded from etcd.
+	Ready bool `json:"ready"`
+	// Connected indicates that the last etcd request or watch response succeeded,
+	// and the etcd client's connection didn't fail since then.
+	// Set the client's DialKeepAliveTime, as the default client does, so a silently dead connection is detected.
+	Connected bool `json:"connected"`
+	// LastUpdate is the time when the settings were last updated.
+	LastUpdate time.Time `json:"last_update"`
+	// KeyCount is the number of the settings.
+	KeyCount int `json:"key_count"`
+	// LastError is the last etcd error if any.
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Status returns the current state of the Config.
+func (c *Config) Status() ConfigStatus {
+	var s ConfigStatus
+
+	select {
+	case <-c.ready:
+		s.Ready = true
+	default:
+	}
+
+	c.settings.Range(func(key interface{}, value interface{}) bool {
+		s.KeyCount++
+		return true
+	})
+
+	c.mu.Lock()
+	s.Connected = c.connected
+	s.LastUpdate = c.updatedAt
+	if c.lastErr != nil {
+		s.LastError = c.lastErr.Error()
+	}
+	c.mu.Unlock()
+
+	return s
+}
+
+// WaitConnected waits until etcd is reachable, i.e., the last etcd request or watch response succeeded.
+// Unlike Ready, it doesn't wait for the settings to be loaded.
+func (c *Config) WaitConnected(ctx context.Context) error {
+	for {
+		c.mu.Lock()
+		connected, changed := c.connected, c.stateChanged
+		c.mu.Unlock()
+		if connected {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return fmt.Errorf("dynconf not connected: %w", ctx.Err())
+		}
+	}
+}
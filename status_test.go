@@ -0,0 +1,128 @@
+package dynconf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestStatus(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	s := c.Status()
+	if s.Ready || s.Connected || !s.LastUpdate.IsZero() || s.KeyCount != 0 || s.LastError != "" {
+		t.Fatalf("expected zero status got %+v", s)
+	}
+
+	// The settings were loaded.
+	c.settings.Store("velocity", "5")
+	c.setState(nil)
+	c.notify()
+	c.markReady()
+	s = c.Status()
+	if !s.Ready || !s.Connected || s.LastUpdate.IsZero() || s.KeyCount != 1 {
+		t.Fatalf("expected loaded status got %+v", s)
+	}
+	loadedAt := s.LastUpdate
+
+	// The settings were updated.
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{putEvent("/configs/curiosity/name", "curiosity", 2)}, 2))
+	s = c.Status()
+	if s.KeyCount != 2 || !s.LastUpdate.After(loadedAt) {
+		t.Fatalf("expected updated status got %+v", s)
+	}
+
+	// The watch failed.
+	c.setState(errors.New("connection refused"))
+	s = c.Status()
+	if s.Connected || s.LastError != "connection refused" {
+		t.Fatalf("expected error status got %+v", s)
+	}
+	if !s.Ready || s.KeyCount != 2 {
+		t.Fatalf("expected the settings to be kept got %+v", s)
+	}
+}
+
+func TestWaitConnected(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		c.setState(nil)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err = c.WaitConnected(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitConnectedTimeout(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err = c.WaitConnected(ctx); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestWatchConnection(t *testing.T) {
+	// etcd is unreachable, so the connection fails.
+	etcd, err := clientv3.New(clientv3.Config{
+		Endpoints: []string{"127.0.0.1:1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := etcd.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s := etcdSource{client: etcd, path: "/configs/curiosity/"}
+	var got SourceUpdate
+	s.watchConnection(ctx, func(u SourceUpdate) bool {
+		got = u
+		return false
+	})
+	if got.Err == nil {
+		t.Errorf("expected connection error got %+v", got)
+	}
+}
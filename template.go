@@ -0,0 +1,41 @@
+package dynconf
+
+import "text/template"
+
+// templateEntry is a parsed template cached along with the value it was parsed from.
+type templateEntry struct {
+	raw  string
+	tmpl *template.Template
+}
+
+// Template returns the text template parsed from the given setting and named after it,
+// or defaultValue if it wasn't found or parsing failed.
+// The parsed template is cached until the setting's value changes.
+func (c *Config) Template(setting string, defaultValue *template.Template) *template.Template {
+	v, ok := c.lookup(setting)
+	if !ok {
+		c.logger.Log("msg", "dynconf setting not found", "path", c.path, "setting", setting, "err", "not found")
+		return defaultValue
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		c.logger.Log("msg", "dynconf invalid string value", "path", c.path, "setting", setting, "value", v)
+		return defaultValue
+	}
+
+	if v, ok := c.templates.Load(setting); ok {
+		if e, _ := v.(*templateEntry); e != nil && e.raw == s {
+			return e.tmpl
+		}
+	}
+
+	tmpl, err := template.New(setting).Parse(s)
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid template setting", "path", c.path, "setting", setting, "value", s, "err", err)
+		return defaultValue
+	}
+	c.templates.Store(setting, &templateEntry{raw: s, tmpl: tmpl})
+
+	return tmpl
+}
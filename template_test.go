@@ -0,0 +1,85 @@
+package dynconf
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/go-kit/log"
+)
+
+func TestConfigTemplate(t *testing.T) {
+	defaultGreeting := template.Must(template.New("default").Parse("Hello"))
+
+	tests := map[string]struct {
+		in   interface{}
+		want string
+	}{
+		"template": {
+			in:   "Hello, {{.Name}}!",
+			want: "Hello, alice!",
+		},
+		"plain text": {
+			in:   "Hi",
+			want: "Hi",
+		},
+		"malformed": {
+			in:   "Hello, {{.Name}!",
+			want: "Hello",
+		},
+		"int": {
+			in:   1,
+			want: "Hello",
+		},
+	}
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Store("greeting", tc.in)
+			var b strings.Builder
+			if err := c.Template("greeting", defaultGreeting).Execute(&b, map[string]string{"Name": "alice"}); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); tc.want != got {
+				t.Errorf("expected %q got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigTemplateCache(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.settings.Store("greeting", "Hello, {{.}}!")
+	tmpl := c.Template("greeting", nil)
+	if got := c.Template("greeting", nil); got != tmpl {
+		t.Errorf("expected cached template")
+	}
+
+	// The template was changed, so the cached template must not be used.
+	c.settings.Store("greeting", "Bye, {{.}}!")
+	if got := c.Template("greeting", nil); got == tmpl {
+		t.Errorf("expected reparsed template")
+	}
+}
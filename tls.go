@@ -0,0 +1,65 @@
+package dynconf
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// tlsEntry is a TLS config cached along with the PEM contents it was built from.
+type tlsEntry struct {
+	pem    string
+	config *tls.Config
+}
+
+// TLSConfig returns a TLS config built from the PEM-encoded certificate, private key and CA certificates
+// stored in the given settings. The CA setting can be empty if no CA pool is needed.
+// The config is cached until any of the PEM values changes.
+func (c *Config) TLSConfig(certSetting, keySetting, caSetting string) (*tls.Config, error) {
+	certPEM, err := c.StringRequired(certSetting)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := c.StringRequired(keySetting)
+	if err != nil {
+		return nil, err
+	}
+	var caPEM string
+	if caSetting != "" {
+		if caPEM, err = c.StringRequired(caSetting); err != nil {
+			return nil, err
+		}
+	}
+
+	cacheKey := certSetting + "\x00" + keySetting + "\x00" + caSetting
+	pem := certPEM + "\x00" + keyPEM + "\x00" + caPEM
+	if v, ok := c.tlsConfigs.Load(cacheKey); ok {
+		if e, _ := v.(*tlsEntry); e != nil && e.pem == pem {
+			return e.config.Clone(), nil
+		}
+	}
+
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		c.logger.Log("msg", "dynconf invalid TLS key pair", "path", c.path, "setting", certSetting, "err", err)
+		return nil, fmt.Errorf("dynconf invalid TLS key pair %s, %s: %w", certSetting, keySetting, err)
+	}
+	conf := tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if caSetting != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caPEM)) {
+			c.logger.Log("msg", "dynconf invalid CA certificate", "path", c.path, "setting", caSetting)
+			return nil, errors.New("dynconf invalid CA certificate: " + caSetting)
+		}
+		conf.RootCAs = pool
+		conf.ClientCAs = pool
+	}
+
+	c.tlsConfigs.Store(cacheKey, &tlsEntry{pem: pem, config: &conf})
+
+	return conf.Clone(), nil
+}
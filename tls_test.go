@@ -0,0 +1,137 @@
+package dynconf
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+// selfSignedPEM generates a self-signed certificate and its private key in PEM.
+func selfSignedPEM(t *testing.T) (certPEM, keyPEM string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "curiosity"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return certPEM, keyPEM
+}
+
+func TestTLSConfig(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	certPEM, keyPEM := selfSignedPEM(t)
+	c.settings.Store("tls_cert", certPEM)
+	c.settings.Store("tls_key", keyPEM)
+	c.settings.Store("tls_ca", certPEM)
+
+	conf, err := c.TLSConfig("tls_cert", "tls_key", "tls_ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conf.Certificates) != 1 {
+		t.Errorf("expected 1 certificate got %d", len(conf.Certificates))
+	}
+	if conf.RootCAs == nil {
+		t.Errorf("expected CA pool")
+	}
+
+	// The cert was rotated, so the cached config must not be used.
+	certPEM, keyPEM = selfSignedPEM(t)
+	c.settings.Store("tls_cert", certPEM)
+	c.settings.Store("tls_key", keyPEM)
+	rotated, err := c.TLSConfig("tls_cert", "tls_key", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rotated.Certificates[0].Certificate[0]) == string(conf.Certificates[0].Certificate[0]) {
+		t.Errorf("expected rotated certificate")
+	}
+}
+
+func TestTLSConfigInvalid(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	certPEM, keyPEM := selfSignedPEM(t)
+
+	tests := map[string]struct {
+		cert, key, ca interface{}
+	}{
+		"malformed cert": {
+			cert: "-----BEGIN CERTIFICATE-----\nbm9wZQ==\n-----END CERTIFICATE-----\n",
+			key:  keyPEM,
+			ca:   certPEM,
+		},
+		"malformed ca": {
+			cert: certPEM,
+			key:  keyPEM,
+			ca:   "not a certificate",
+		},
+		"missing key": {
+			cert: certPEM,
+			ca:   certPEM,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.settings.Delete("tls_key")
+			c.settings.Store("tls_cert", tc.cert)
+			if tc.key != nil {
+				c.settings.Store("tls_key", tc.key)
+			}
+			c.settings.Store("tls_ca", tc.ca)
+
+			if _, err := c.TLSConfig("tls_cert", "tls_key", "tls_ca"); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package dynconf
+
+// Validator checks all the settings together, see WithValidator.
+type Validator interface {
+	// Validate returns an error describing why the settings are invalid.
+	Validate(settings map[string]string) error
+}
+
+// ValidatorFunc is a function implementing Validator.
+type ValidatorFunc func(settings map[string]string) error
+
+// Validate calls f.
+func (f ValidatorFunc) Validate(settings map[string]string) error {
+	return f(settings)
+}
+
+// validUpdate reports whether the settings resulting from the events pass the validator.
+// The values are the events' values to be stored, and the ones rejected by the schema are skipped.
+func (c *Config) validUpdate(events []SourceEvent, values []string) bool {
+	if c.validator == nil || len(events) == 0 {
+		return true
+	}
+
+	settings := c.Settings()
+	if settings == nil {
+		settings = make(map[string]string)
+	}
+	for i, e := range events {
+		switch {
+		case e.Deleted:
+			delete(settings, e.Setting)
+		case c.schema == nil || c.schema.Validate(e.Setting, values[i]) == nil:
+			settings[e.Setting] = values[i]
+		}
+	}
+
+	return c.validSnapshot(settings)
+}
+
+// validSnapshot reports whether the settings pass the validator set with WithValidator,
+// the errors are logged and reported to the onInvalidSettings callback.
+func (c *Config) validSnapshot(settings map[string]string) bool {
+	if c.validator == nil {
+		return true
+	}
+	err := c.validator.Validate(settings)
+	if err == nil {
+		return true
+	}
+
+	// The validator's error may quote any of the values, so they're all masked.
+	var logged interface{} = err
+	if c.maskValues {
+		logged = maskSettings(err.Error(), settings)
+	}
+	c.logger.Log("msg", "dynconf rejected invalid settings", "path", c.path, "err", logged)
+	if c.onInvalidSettings != nil {
+		c.protect("onInvalidSettings", func() { c.onInvalidSettings(err) })
+	}
+
+	return false
+}
@@ -0,0 +1,64 @@
+package dynconf
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/go-kit/log"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestWithValidator(t *testing.T) {
+	// The max velocity must be greater than the velocity.
+	v := ValidatorFunc(func(settings map[string]string) error {
+		velocity, _ := strconv.Atoi(settings["velocity"])
+		max, _ := strconv.Atoi(settings["max_velocity"])
+		if velocity > max {
+			return errors.New("velocity exceeds max_velocity")
+		}
+		return nil
+	})
+	var rejected []error
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithValidator(v, func(err error) {
+		rejected = append(rejected, err)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+		putEvent("/configs/curiosity/velocity", "5", 2),
+		putEvent("/configs/curiosity/max_velocity", "10", 2),
+	}, 2))
+	if got := c.Integer("velocity", 0); got != 5 {
+		t.Fatalf("expected velocity 5 got %d", got)
+	}
+
+	// The update is rejected as a whole.
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+		putEvent("/configs/curiosity/name", "curiosity", 3),
+		putEvent("/configs/curiosity/velocity", "50", 3),
+	}, 3))
+	if got := c.Integer("velocity", 0); got != 5 {
+		t.Errorf("expected the valid velocity 5 got %d", got)
+	}
+	if c.Has("name") {
+		t.Error("expected name to be rejected")
+	}
+	if len(rejected) != 1 {
+		t.Errorf("expected one rejected update got %v", rejected)
+	}
+
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{deleteEvent("/configs/curiosity/max_velocity", 4)}, 4))
+	if got := c.Integer("max_velocity", 0); got != 10 {
+		t.Errorf("expected max velocity 10 got %d", got)
+	}
+}
@@ -0,0 +1,100 @@
+package dynconf
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Value holds the parsed value of a setting which is updated whenever the setting changes,
+// so the hot paths can read it without parsing the setting every time, see Register.
+type Value[T any] struct {
+	// v holds *T.
+	v atomic.Value
+	// mu serializes parsing and storing the value, so a stale value can't overwrite a newer one.
+	mu sync.Mutex
+	// remove removes the listener updating v.
+	remove    func()
+	closeOnce sync.Once
+}
+
+// Load returns the latest value of the setting.
+func (v *Value[T]) Load() T {
+	return *v.v.Load().(*T)
+}
+
+// Close stops updating the value, so Load keeps returning the last one.
+func (v *Value[T]) Close() {
+	v.closeOnce.Do(v.remove)
+}
+
+// Register returns a Value of the given setting which is parsed according to T,
+// or defaultValue if it wasn't found or parsing failed, e.g.,
+//
+//	velocity := dynconf.Register(c, "velocity", 10)
+//	...
+//	v := velocity.Load()
+//
+// Close the Value once it's no longer used, so the Config stops updating it.
+// T can be string, bool, int, int64, uint, uint64, float64, or time.Duration,
+// any other type is decoded from the JSON value as Struct does.
+func Register[T any](c *Config, setting string, defaultValue T) *Value[T] {
+	parse := parser(c, setting, defaultValue)
+
+	v := new(Value[T])
+	v.v.Store(&defaultValue)
+	update := func() {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		x := parse()
+		v.v.Store(&x)
+	}
+	// The listener is added first, so the changes made while parsing the current value aren't missed.
+	v.remove = c.AddListener(func(changes []Change) {
+		for _, ch := range changes {
+			if ch.Setting == setting {
+				update()
+				return
+			}
+		}
+	})
+	update()
+
+	return v
+}
+
+// parser returns a function which parses the setting with the getter of T.
+func parser[T any](c *Config, setting string, defaultValue T) func() T {
+	var get func() interface{}
+	switch d := interface{}(defaultValue).(type) {
+	case string:
+		get = func() interface{} { return c.String(setting, d) }
+	case bool:
+		get = func() interface{} { return c.Boolean(setting, d) }
+	case int:
+		get = func() interface{} { return c.Integer(setting, d) }
+	case int64:
+		get = func() interface{} { return c.Int64(setting, d) }
+	case uint:
+		get = func() interface{} { return c.Uint(setting, d) }
+	case uint64:
+		get = func() interface{} { return c.Uint64(setting, d) }
+	case float64:
+		get = func() interface{} { return c.Float(setting, d) }
+	case time.Duration:
+		get = func() interface{} { return c.Duration(setting, d) }
+	default:
+		return func() T {
+			var x T
+			if err := c.Struct(setting, &x); err != nil {
+				c.logger.Log("msg", "dynconf invalid struct setting", "path", c.path, "setting", setting, "err", c.maskedErr(err))
+				return defaultValue
+			}
+			return x
+		}
+	}
+
+	return func() T {
+		return get().(T)
+	}
+}
@@ -0,0 +1,71 @@
+package dynconf
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestRegister(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	type camera struct {
+		Enabled bool `json:"enabled"`
+	}
+	c.settings.Store("velocity", "5")
+	velocity := Register(c, "velocity", 10)
+	timeout := Register(c, "timeout", time.Second)
+	cam := Register(c, "camera", camera{})
+
+	if got := velocity.Load(); got != 5 {
+		t.Errorf("expected velocity 5 got %d", got)
+	}
+	if got := timeout.Load(); got != time.Second {
+		t.Errorf("expected default timeout got %s", got)
+	}
+
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+		putEvent("/configs/curiosity/velocity", "7", 2),
+		putEvent("/configs/curiosity/timeout", "5s", 2),
+		putEvent("/configs/curiosity/camera", `{"enabled": true}`, 2),
+	}, 2))
+	if got := velocity.Load(); got != 7 {
+		t.Errorf("expected velocity 7 got %d", got)
+	}
+	if got := timeout.Load(); got != 5*time.Second {
+		t.Errorf("expected timeout 5s got %s", got)
+	}
+	if got := cam.Load(); !got.Enabled {
+		t.Errorf("expected camera to be enabled")
+	}
+
+	// The invalid value falls back to the default.
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+		putEvent("/configs/curiosity/velocity", "fast", 3),
+	}, 3))
+	if got := velocity.Load(); got != 10 {
+		t.Errorf("expected default velocity got %d", got)
+	}
+
+	// The closed value isn't updated anymore.
+	velocity.Close()
+	velocity.Close()
+	c.update(newEtcdUpdate(c.path, []*clientv3.Event{
+		putEvent("/configs/curiosity/velocity", "9", 4),
+	}, 4))
+	if got := velocity.Load(); got != 10 {
+		t.Errorf("expected the last velocity 10 got %d", got)
+	}
+}
@@ -0,0 +1,282 @@
+package dynconf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// SetDefault registers the default value of the given setting,
+// so it can be restored later with ResetToDefault.
+func (c *Config) SetDefault(setting, value string) {
+	c.defaults.Store(setting, value)
+}
+
+// ResetToDefault writes the registered default value of the given setting back to etcd.
+// It returns an error if no default was registered for the setting with SetDefault.
+func (c *Config) ResetToDefault(ctx context.Context, setting string) error {
+	v, ok := c.defaults.Load(setting)
+	if !ok {
+		return fmt.Errorf("dynconf default not registered: %s", setting)
+	}
+	value, _ := v.(string)
+
+	return c.Set(ctx, setting, value)
+}
+
+// Set writes the value of the given setting to etcd,
+// which is canonicalized if WithCanonicalWrites is set.
+func (c *Config) Set(ctx context.Context, setting, value string) error {
+	if err := validateSetting(setting); err != nil {
+		return err
+	}
+	if c.canonicalWrites {
+		value = canonicalize(value)
+	}
+
+	if c.dryRun {
+		if err := c.dryRunCheck(c.Settings(), map[string]string{setting: value}); err != nil {
+			return err
+		}
+		c.logger.Log("msg", "dynconf dry-run set", "path", c.path, "setting", setting, "value", value)
+		return nil
+	}
+
+	if c.etcd == nil {
+		return errNoEtcd
+	}
+	if _, err := c.etcd.Put(ctx, c.path+setting, value); err != nil {
+		c.logger.Log("msg", "dynconf failed to set setting", "path", c.path, "setting", setting, "err", err)
+		return fmt.Errorf("dynconf failed to set setting %s: %w", setting, err)
+	}
+
+	return nil
+}
+
+// SetBool writes the boolean value of the given setting to etcd.
+func (c *Config) SetBool(ctx context.Context, setting string, value bool) error {
+	return c.Set(ctx, setting, strconv.FormatBool(value))
+}
+
+// SetInt writes the integer value of the given setting to etcd.
+func (c *Config) SetInt(ctx context.Context, setting string, value int) error {
+	return c.Set(ctx, setting, strconv.Itoa(value))
+}
+
+// SetFloat writes the float value of the given setting to etcd.
+func (c *Config) SetFloat(ctx context.Context, setting string, value float64) error {
+	return c.Set(ctx, setting, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// SetBatch writes the values of the given settings to etcd in a single transaction,
+// so either all of them are written or none.
+// The values are canonicalized if WithCanonicalWrites is set.
+func (c *Config) SetBatch(ctx context.Context, settings map[string]string) error {
+	values := make(map[string]string, len(settings))
+	ops := make([]clientv3.Op, 0, len(settings))
+	for setting, value := range settings {
+		if err := validateSetting(setting); err != nil {
+			return err
+		}
+		if c.canonicalWrites {
+			value = canonicalize(value)
+		}
+		values[setting] = value
+		ops = append(ops, clientv3.OpPut(c.path+setting, value))
+	}
+
+	if c.dryRun {
+		if err := c.dryRunCheck(c.Settings(), values); err != nil {
+			return err
+		}
+		c.logger.Log("msg", "dynconf dry-run set batch", "path", c.path, "settings", fmt.Sprint(values))
+		return nil
+	}
+
+	if c.etcd == nil {
+		return errNoEtcd
+	}
+	if _, err := c.etcd.Txn(ctx).Then(ops...).Commit(); err != nil {
+		c.logger.Log("msg", "dynconf failed to set settings", "path", c.path, "err", err)
+		return fmt.Errorf("dynconf failed to set settings: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the given setting from etcd.
+func (c *Config) Delete(ctx context.Context, setting string) error {
+	if err := validateSetting(setting); err != nil {
+		return err
+	}
+
+	if c.dryRun {
+		remaining := c.Settings()
+		delete(remaining, setting)
+		if err := c.dryRunCheck(remaining, nil); err != nil {
+			return err
+		}
+		c.logger.Log("msg", "dynconf dry-run delete", "path", c.path, "setting", setting)
+		return nil
+	}
+
+	if c.etcd == nil {
+		return errNoEtcd
+	}
+	if _, err := c.etcd.Delete(ctx, c.path+setting); err != nil {
+		c.logger.Log("msg", "dynconf failed to delete setting", "path", c.path, "setting", setting, "err", err)
+		return fmt.Errorf("dynconf failed to delete setting %s: %w", setting, err)
+	}
+
+	return nil
+}
+
+// Replace atomically replaces all the settings under the path with the given ones:
+// the settings absent in the new set are deleted and the others are written in a single transaction.
+// It's all-or-nothing, i.e., either the whole new set is in etcd or nothing has changed.
+// If the settings are modified concurrently, the replacement is retried until ctx expires.
+func (c *Config) Replace(ctx context.Context, settings map[string]string) error {
+	for setting := range settings {
+		if err := validateSetting(setting); err != nil {
+			return err
+		}
+	}
+
+	if c.dryRun {
+		if err := c.dryRunCheck(nil, settings); err != nil {
+			return err
+		}
+		c.logger.Log("msg", "dynconf dry-run replace", "path", c.path, "settings", fmt.Sprint(settings))
+		return nil
+	}
+
+	if c.etcd == nil {
+		return errNoEtcd
+	}
+	for {
+		r, err := c.etcd.Get(ctx, c.path, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+		if err != nil {
+			return fmt.Errorf("dynconf failed to replace settings: %w", err)
+		}
+
+		ops := make([]clientv3.Op, 0, len(r.Kvs)+len(settings))
+		for _, kv := range r.Kvs {
+			if _, ok := settings[string(kv.Key)[len(c.path):]]; !ok {
+				ops = append(ops, clientv3.OpDelete(string(kv.Key)))
+			}
+		}
+		for setting, value := range settings {
+			ops = append(ops, clientv3.OpPut(c.path+setting, value))
+		}
+
+		// The transaction fails if any setting was modified since it was fetched.
+		unchanged := clientv3.Compare(clientv3.ModRevision(c.path), "<", r.Header.Revision+1).WithPrefix()
+		t, err := c.etcd.Txn(ctx).If(unchanged).Then(ops...).Commit()
+		if err != nil {
+			c.logger.Log("msg", "dynconf failed to replace settings", "path", c.path, "err", err)
+			return fmt.Errorf("dynconf failed to replace settings: %w", err)
+		}
+		if t.Succeeded {
+			return nil
+		}
+	}
+}
+
+// SeedDefaults writes the given default settings to etcd unless they're already present,
+// so it's safe to call on every boot. The existing settings are never overwritten.
+func (c *Config) SeedDefaults(ctx context.Context, defaults map[string]string) error {
+	for setting := range defaults {
+		if err := validateSetting(setting); err != nil {
+			return err
+		}
+	}
+
+	if c.dryRun {
+		// The settings which are already present aren't seeded.
+		current := c.Settings()
+		missing := make(map[string]string, len(defaults))
+		for setting, value := range defaults {
+			if _, ok := current[setting]; !ok {
+				missing[setting] = value
+			}
+		}
+		if err := c.dryRunCheck(current, missing); err != nil {
+			return err
+		}
+	}
+	if c.etcd == nil && !c.dryRun {
+		return errNoEtcd
+	}
+	for setting, value := range defaults {
+		if c.dryRun {
+			c.logger.Log("msg", "dynconf dry-run seed", "path", c.path, "setting", setting, "value", value)
+			continue
+		}
+
+		key := c.path + setting
+		_, err := c.etcd.Txn(ctx).
+			If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+			Then(clientv3.OpPut(key, value)).
+			Commit()
+		if err != nil {
+			c.logger.Log("msg", "dynconf failed to seed setting", "path", c.path, "setting", setting, "err", err)
+			return fmt.Errorf("dynconf failed to seed setting %s: %w", setting, err)
+		}
+	}
+
+	return nil
+}
+
+// canonicalize returns the canonical form of a boolean or numeric value,
+// other values are returned as is.
+func canonicalize(value string) string {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return strconv.FormatInt(i, 10)
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	if b, err := strconv.ParseBool(value); err == nil {
+		return strconv.FormatBool(b)
+	}
+
+	return value
+}
+
+// dryRunCheck checks the values against the schema, and the settings resulting from writing them
+// against the validator, so a dry run fails if the Config would reject the changes.
+func (c *Config) dryRunCheck(settings, values map[string]string) error {
+	if settings == nil {
+		settings = make(map[string]string, len(values))
+	}
+	for setting, value := range values {
+		value = c.expand(setting, value)
+		if c.schema != nil {
+			if err := c.schema.Validate(setting, value); err != nil {
+				return fmt.Errorf("dynconf invalid setting %s: %w", setting, err)
+			}
+		}
+		settings[setting] = value
+	}
+
+	if c.validator != nil {
+		if err := c.validator.Validate(settings); err != nil {
+			return fmt.Errorf("dynconf invalid settings: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateSetting checks that the setting name can be written to etcd.
+func validateSetting(setting string) error {
+	if setting == "" {
+		return errors.New("dynconf setting name is empty")
+	}
+
+	return nil
+}
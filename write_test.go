@@ -0,0 +1,407 @@
+package dynconf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/google/go-cmp/cmp"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// newEtcdClient returns a client of etcd running on 127.0.0.1:2379.
+// The test is skipped if etcd isn't reachable.
+func newEtcdClient(t *testing.T) *clientv3.Client {
+	t.Helper()
+
+	etcd, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{"127.0.0.1:2379"},
+		DialTimeout: time.Second,
+	})
+	if err != nil {
+		t.Skipf("etcd is not available: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err = etcd.Get(ctx, "health"); err != nil {
+		etcd.Close()
+		t.Skipf("etcd is not available: %v", err)
+	}
+
+	return etcd
+}
+
+func TestResetToDefault(t *testing.T) {
+	etcd := newEtcdClient(t)
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithEtcdClient(etcd), WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if r, err := etcd.Put(ctx, "/configs/curiosity/velocity", "20"); err != nil {
+		t.Fatalf("failed to put velocity=20 setting: %v %v", err, r)
+	}
+
+	c.SetDefault("velocity", "5")
+	if err = c.ResetToDefault(ctx, "velocity"); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := etcd.Get(ctx, "/configs/curiosity/velocity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Kvs) != 1 {
+		t.Fatalf("expected velocity setting got %d keys", len(r.Kvs))
+	}
+	if got := string(r.Kvs[0].Value); got != "5" {
+		t.Errorf("expected velocity %q got %q", "5", got)
+	}
+}
+
+func TestResetToDefaultNotRegistered(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if err = c.ResetToDefault(context.Background(), "velocity"); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithDryRun())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err = c.Set(ctx, "velocity", "10"); err != nil {
+		t.Errorf("set: %v", err)
+	}
+	if err = c.SetBatch(ctx, map[string]string{"velocity": "10", "is_camera_enabled": "true"}); err != nil {
+		t.Errorf("set batch: %v", err)
+	}
+	if err = c.Delete(ctx, "velocity"); err != nil {
+		t.Errorf("delete: %v", err)
+	}
+
+	if err = c.Set(ctx, "", "10"); err == nil {
+		t.Errorf("set: expected error")
+	}
+	if err = c.SetBatch(ctx, map[string]string{"": "10"}); err == nil {
+		t.Errorf("set batch: expected error")
+	}
+	if err = c.Delete(ctx, ""); err == nil {
+		t.Errorf("delete: expected error")
+	}
+}
+
+func TestDryRunValidation(t *testing.T) {
+	schema := NewSchema().IntegerRange("velocity", 0, 100)
+	// The max velocity must be greater than the velocity.
+	v := ValidatorFunc(func(settings map[string]string) error {
+		velocity, _ := strconv.Atoi(settings["velocity"])
+		max, _ := strconv.Atoi(settings["max_velocity"])
+		if velocity > max {
+			return errors.New("velocity exceeds max_velocity")
+		}
+		return nil
+	})
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithLogger(logger), WithDryRun(), WithSchema(schema, nil), WithValidator(v, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	c.settings.Store("velocity", "5")
+	c.settings.Store("max_velocity", "10")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err = c.Set(ctx, "velocity", "8"); err != nil {
+		t.Errorf("set: %v", err)
+	}
+	if err = c.Set(ctx, "velocity", "fast"); err == nil {
+		t.Error("set: expected schema error")
+	}
+	if err = c.Set(ctx, "velocity", "20"); err == nil {
+		t.Error("set: expected validator error")
+	}
+	if err = c.SetBatch(ctx, map[string]string{"velocity": "20", "max_velocity": "30"}); err != nil {
+		t.Errorf("set batch: %v", err)
+	}
+	if err = c.Delete(ctx, "max_velocity"); err == nil {
+		t.Error("delete: expected validator error")
+	}
+	if err = c.Replace(ctx, map[string]string{"velocity": "1"}); err == nil {
+		t.Error("replace: expected validator error")
+	}
+	if err = c.SeedDefaults(ctx, map[string]string{"velocity": "50"}); err != nil {
+		t.Errorf("seed defaults: %v", err)
+	}
+}
+
+func TestDryRunEtcdUntouched(t *testing.T) {
+	etcd := newEtcdClient(t)
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithEtcdClient(etcd), WithLogger(logger), WithDryRun())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if r, err := etcd.Put(ctx, "/configs/curiosity/velocity", "5"); err != nil {
+		t.Fatalf("failed to put velocity=5 setting: %v %v", err, r)
+	}
+
+	if err = c.Set(ctx, "velocity", "10"); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Delete(ctx, "velocity"); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := etcd.Get(ctx, "/configs/curiosity/velocity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Kvs) != 1 || string(r.Kvs[0].Value) != "5" {
+		t.Errorf("expected velocity %q got %v", "5", r.Kvs)
+	}
+}
+
+func TestSeedDefaults(t *testing.T) {
+	etcd := newEtcdClient(t)
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithEtcdClient(etcd), WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if r, err := etcd.Put(ctx, "/configs/curiosity/velocity", "20"); err != nil {
+		t.Fatalf("failed to put velocity=20 setting: %v %v", err, r)
+	}
+	if r, err := etcd.Delete(ctx, "/configs/curiosity/is_camera_enabled"); err != nil {
+		t.Fatalf("failed to delete is_camera_enabled setting: %v %v", err, r)
+	}
+
+	err = c.SeedDefaults(ctx, map[string]string{
+		"velocity":          "5",
+		"is_camera_enabled": "true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"/configs/curiosity/velocity":          "20",
+		"/configs/curiosity/is_camera_enabled": "true",
+	}
+	for key, value := range want {
+		r, err := etcd.Get(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Kvs) != 1 || string(r.Kvs[0].Value) != value {
+			t.Errorf("expected %s=%q got %v", key, value, r.Kvs)
+		}
+	}
+}
+
+func TestReplace(t *testing.T) {
+	etcd := newEtcdClient(t)
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/replace/", WithEtcdClient(etcd), WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if r, err := etcd.Put(ctx, "/configs/replace/velocity", "5"); err != nil {
+		t.Fatalf("failed to put velocity=5 setting: %v %v", err, r)
+	}
+	if r, err := etcd.Put(ctx, "/configs/replace/name", "curiosity"); err != nil {
+		t.Fatalf("failed to put name=curiosity setting: %v %v", err, r)
+	}
+
+	err = c.Replace(ctx, map[string]string{
+		"velocity":          "10",
+		"is_camera_enabled": "true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := etcd.Get(ctx, "/configs/replace/", clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, kv := range r.Kvs {
+		got[string(kv.Key)] = string(kv.Value)
+	}
+	want := map[string]string{
+		"/configs/replace/velocity":          "10",
+		"/configs/replace/is_camera_enabled": "true",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	tests := map[string]struct {
+		in   string
+		want string
+	}{
+		"bool upper case": {in: "TRUE", want: "true"},
+		"bool short":      {in: "f", want: "false"},
+		"int leading 0":   {in: "010", want: "10"},
+		"int plus sign":   {in: "+5", want: "5"},
+		"float":           {in: "1.50", want: "1.5"},
+		"float exponent":  {in: "1e3", want: "1000"},
+		"inf":             {in: "inf", want: "inf"},
+		"string":          {in: "alice", want: "alice"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := canonicalize(tc.in); tc.want != got {
+				t.Errorf("expected %q got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCanonicalWrites(t *testing.T) {
+	etcd := newEtcdClient(t)
+
+	logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	c, err := New("/configs/curiosity/", WithEtcdClient(etcd), WithLogger(logger), WithCanonicalWrites())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err = c.Set(ctx, "is_camera_enabled", "TRUE"); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Set(ctx, "velocity", "010"); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.SetBatch(ctx, map[string]string{"mass": "899.0", "name": "Curiosity"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"/configs/curiosity/is_camera_enabled": "true",
+		"/configs/curiosity/velocity":          "10",
+		"/configs/curiosity/mass":              "899",
+		"/configs/curiosity/name":              "Curiosity",
+	}
+	for key, value := range want {
+		r, err := etcd.Get(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Kvs) != 1 || string(r.Kvs[0].Value) != value {
+			t.Errorf("expected %s=%q got %v", key, value, r.Kvs)
+		}
+	}
+}
+
+func TestCanonicalWritesDryRun(t *testing.T) {
+	var buf bytes.Buffer
+	c, err := New("/configs/curiosity/", WithLogger(log.NewLogfmtLogger(&buf)), WithDryRun(), WithCanonicalWrites())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err = c.Set(ctx, "is_camera_enabled", "TRUE"); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.SetBatch(ctx, map[string]string{"velocity": "010"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "setting=is_camera_enabled value=true") {
+		t.Errorf("expected canonical bool got %s", out)
+	}
+	if !strings.Contains(out, "settings=map[velocity:10]") {
+		t.Errorf("expected canonical int got %s", out)
+	}
+}